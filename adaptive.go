@@ -0,0 +1,144 @@
+package atomic
+
+import (
+	"math"
+	"runtime"
+	"sync/atomic"
+)
+
+// adaptivePromoted is the bit pattern of the single word of an
+// adaptiveAtomicFloat once its value has moved to sharded storage. It is a
+// signaling NaN sentinel, as described alongside emaUnset, which Store and
+// Swap replace with a quiet NaN.
+const adaptivePromoted = 0x7ff4000000000001
+
+// defaultPromotionThreshold is the number of failed compare-and-swap
+// operations after which an adaptiveAtomicFloat promotes itself, unless
+// otherwise specified.
+const defaultPromotionThreshold = 1024
+
+// adaptiveAtomicFloat is an atomic float that behaves like atomicFloatCAS while
+// uncontended, but counts its failed compare-and-swap operations, and once they
+// reach a threshold, permanently promotes itself to an atomicFloatSharded, so
+// that heavily contended adds scale at the expense of slower loads.
+//
+// Promotion swaps the single word for a sentinel, and then publishes the
+// sharded storage initialized to the value it held. Updates racing with
+// promotion either commit to the single word before the sentinel, and so are
+// carried over, or observe the sentinel and are redirected to the shards, so
+// no update is ever lost. Operations that observe the sentinel before the
+// shards are published wait for them.
+type adaptiveAtomicFloat struct {
+	u64       atomic.Uint64
+	failures  atomic.Uint64
+	sharded   atomic.Pointer[atomicFloatSharded] // nil until promoted
+	threshold uint64
+}
+
+// NewAtomicFloatAdaptive returns an atomic float initialized to initial, which
+// promotes itself to sharded storage after threshold failed compare-and-swap
+// operations, or after 1024 when threshold is not positive.
+func NewAtomicFloatAdaptive(initial float64, threshold int) *adaptiveAtomicFloat {
+	a := &adaptiveAtomicFloat{threshold: defaultPromotionThreshold}
+	if threshold > 0 {
+		a.threshold = uint64(threshold)
+	}
+	a.u64.Store(quietPromoted(math.Float64bits(initial)))
+	return a
+}
+
+// quietPromoted returns bits, unless they are the promotion sentinel, in which
+// case it returns the bits of a quiet NaN instead.
+func quietPromoted(bits uint64) uint64 {
+	if bits == adaptivePromoted {
+		return math.Float64bits(math.NaN())
+	}
+	return bits
+}
+
+// shards returns the sharded storage, waiting for it to be published when
+// promotion is in progress.
+func (a *adaptiveAtomicFloat) shards() *atomicFloatSharded {
+	for {
+		if s := a.sharded.Load(); s != nil {
+			return s
+		}
+		runtime.Gosched()
+	}
+}
+
+// contended records a failed compare-and-swap operation, and promotes the
+// atomic float once the threshold is reached.
+func (a *adaptiveAtomicFloat) contended() {
+	if a.failures.Add(1) == a.threshold {
+		a.promote()
+	}
+}
+
+// promote moves the value from the single word to sharded storage, unless that
+// has already happened.
+func (a *adaptiveAtomicFloat) promote() {
+	for {
+		oldBits := a.u64.Load()
+		if oldBits == adaptivePromoted {
+			return
+		}
+		if a.u64.CompareAndSwap(oldBits, adaptivePromoted) {
+			a.sharded.Store(NewAtomicFloatSharded(math.Float64frombits(oldBits)))
+			return
+		}
+	}
+}
+
+// Promoted returns true once the atomic float has promoted itself to sharded
+// storage.
+func (a *adaptiveAtomicFloat) Promoted() bool {
+	return a.u64.Load() == adaptivePromoted
+}
+
+// Add attempts to add delta to the value stored in the atomic float and return
+// the new value. Once promoted, the returned value is that of
+// atomicFloatSharded.Add, so it is not linearizable with respect to concurrent
+// adds.
+func (a *adaptiveAtomicFloat) Add(delta float64) float64 {
+	for {
+		oldBits := a.u64.Load()
+		if oldBits == adaptivePromoted {
+			return a.shards().Add(delta)
+		}
+		newValue := math.Float64frombits(oldBits) + delta
+		if a.u64.CompareAndSwap(oldBits, math.Float64bits(newValue)) {
+			return newValue
+		}
+		a.contended()
+	}
+}
+
+// Load atomically loads the current atomic float value, folding the shards
+// once promoted.
+func (a *adaptiveAtomicFloat) Load() float64 {
+	if bits := a.u64.Load(); bits != adaptivePromoted {
+		return math.Float64frombits(bits)
+	}
+	return a.shards().Load()
+}
+
+// Store atomically stores new into the atomic float.
+func (a *adaptiveAtomicFloat) Store(new float64) {
+	a.Swap(new)
+}
+
+// Swap atomically stores new and returns the previous value.
+func (a *adaptiveAtomicFloat) Swap(new float64) float64 {
+	newBits := quietPromoted(math.Float64bits(new))
+	for {
+		oldBits := a.u64.Load()
+		if oldBits == adaptivePromoted {
+			return a.shards().Swap(math.Float64frombits(newBits))
+		}
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			return math.Float64frombits(oldBits)
+		}
+		a.contended()
+	}
+}
@@ -0,0 +1,71 @@
+package atomic
+
+import (
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+// TestAlignment guards against regressing 64-bit alignment of the lock-free
+// types. On 32-bit platforms such as 386 and arm, 64-bit atomic operations
+// panic unless their operand is 8-byte aligned, and the compiler only
+// guarantees that for a plain uint64 when it is the first word of an
+// allocation. The lock-free types therefore store their bits in an
+// atomic.Uint64, available since Go 1.19, which is aligned wherever it is
+// placed. Only on 32-bit platforms can this test fail; elsewhere every uint64
+// is naturally aligned.
+func TestAlignment(t *testing.T) {
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		t.Logf("alignment is only at risk on 32-bit platforms; GOARCH=%s", runtime.GOARCH)
+	}
+
+	// Each element begins with a field smaller than 8 bytes, so without an
+	// alignment guarantee, the atomic float after it would be misaligned.
+	type element struct {
+		b        byte
+		cas      atomicFloatCAS
+		u32      uint32
+		cas2     atomicFloatCAS2
+		i8       int8
+		backoff  atomicFloatBackoffCAS
+		i16      int16
+		pause    atomicFloatPauseCAS
+		f32      float32
+		number   Number[float64]
+		number32 Number[float32]
+	}
+
+	aligned := func(t *testing.T, name string, p unsafe.Pointer) {
+		t.Helper()
+		if got := uintptr(p) % 8; got != 0 {
+			t.Fatalf("%s: GOT: %v; WANT: 0", name, got)
+		}
+	}
+
+	elements := make([]element, 100)
+	pointers := make([]*element, 100)
+	for i := range pointers {
+		pointers[i] = new(element)
+	}
+	for _, slice := range [][]*element{pointers, {&elements[0], &elements[len(elements)-1]}} {
+		for _, e := range slice {
+			aligned(t, "cas", unsafe.Pointer(&e.cas.u64))
+			aligned(t, "cas2", unsafe.Pointer(&e.cas2.u64))
+			aligned(t, "backoff", unsafe.Pointer(&e.backoff.u64))
+			aligned(t, "pause", unsafe.Pointer(&e.pause.u64))
+			aligned(t, "number", unsafe.Pointer(&e.number.u64))
+		}
+	}
+
+	// Exercise the values, which panics on misaligned 64-bit atomics.
+	for i := range elements {
+		e := &elements[i]
+		for _, af := range []AtomicFloat{&e.cas, &e.cas2, &e.backoff, &e.pause, &e.number} {
+			af.Add(1)
+			if got, want := af.Load(), 1.0; got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+		}
+		e.number32.Add(1)
+	}
+}
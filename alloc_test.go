@@ -0,0 +1,45 @@
+package atomic
+
+import "testing"
+
+// TestAllocations guards the hot paths of the lock-free implementations
+// against regressing into heap allocation, such as by introducing closures.
+// The pointer-swapping implementations, which allocate a new state for every
+// write by design, are checked against their expected allocations instead.
+func TestAllocations(t *testing.T) {
+	c := func(t *testing.T, name string, af AtomicFloat, writeAllocs float64) {
+		t.Run(name, func(t *testing.T) {
+			ops := []struct {
+				name   string
+				fn     func()
+				allocs float64
+			}{
+				{"Add", func() { af.Add(1) }, writeAllocs},
+				{"Load", func() { af.Load() }, 0},
+				{"Store", func() { af.Store(2) }, writeAllocs},
+				{"Swap", func() { af.Swap(3) }, writeAllocs},
+			}
+			for _, op := range ops {
+				if got, want := testing.AllocsPerRun(100, op.fn), op.allocs; got != want {
+					t.Errorf("%s: GOT: %v; WANT: %v", op.name, got, want)
+				}
+			}
+		})
+	}
+
+	c(t, "cas", NewAtomicFloatCAS(0), 0)
+	c(t, "cas-options", NewAtomicFloatCAS(0, WithStrictFinite(), WithBackoff(NoBackoff{})), 0)
+	c(t, "cas2", NewAtomicFloatCAS2(0), 0)
+	c(t, "padded", NewPaddedAtomicFloatCAS(0), 0)
+	c(t, "backoff", NewAtomicFloatBackoffCAS(0, 4, 16), 0)
+	c(t, "pause", NewAtomicFloatPauseCAS(0), 0)
+	c(t, "instrumented", NewAtomicFloatInstrumentedCAS(0), 0)
+	c(t, "number", NewNumber[float64](0), 0)
+	c(t, "sharded", NewAtomicFloatSharded(0), 0)
+	c(t, "seqlock", NewAtomicFloatSeqlock(0), 0)
+
+	// One new value, or compensated sum, per write.
+	c(t, "ptr", NewAtomicFloatPtr(0), 1)
+	c(t, "kahan", NewAtomicFloatKahanCAS(0), 1)
+	c(t, "neumaier", NewAtomicFloatNeumaier(0), 1)
+}
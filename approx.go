@@ -0,0 +1,47 @@
+package atomic
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// ApproxCounter is a lock-free Morris approximate counter, which counts up to
+// very large numbers of events in very little space by storing only the
+// approximate base-2 logarithm of the count. Each Inc increments the stored
+// exponent c with probability 2^-c, so that the estimate 2^c-1 is an unbiased
+// estimate of the number of events. The standard deviation of the estimate is
+// approximately n/√2 for n events, so individual counters are very rough, and
+// averaging several independent counters improves accuracy.
+type ApproxCounter struct {
+	c      atomic.Uint64
+	random func() float64
+}
+
+// NewApproxCounter returns a new ApproxCounter that draws random numbers in
+// the half-open interval [0, 1) by calling random, which must be safe for
+// concurrent use. When random is nil, rand.Float64 from math/rand/v2 is used.
+func NewApproxCounter(random func() float64) *ApproxCounter {
+	if random == nil {
+		random = rand.Float64
+	}
+	return &ApproxCounter{random: random}
+}
+
+// Inc probabilistically records a single event.
+func (a *ApproxCounter) Inc() {
+	for {
+		c := a.c.Load()
+		if a.random() >= math.Ldexp(1, -int(c)) {
+			return
+		}
+		if a.c.CompareAndSwap(c, c+1) {
+			return
+		}
+	}
+}
+
+// Estimate returns the estimated number of events recorded.
+func (a *ApproxCounter) Estimate() float64 {
+	return math.Exp2(float64(a.c.Load())) - 1
+}
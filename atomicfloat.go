@@ -0,0 +1,38 @@
+package atomic
+
+// AtomicFloat is the set of methods shared by every atomic float
+// implementation in this package. Code that accepts an AtomicFloat may be
+// handed any of the implementations, allowing the strategy to be changed
+// without changing the caller.
+type AtomicFloat interface {
+	// Add atomically adds delta to the value and returns the new value.
+	Add(float64) float64
+	// Load atomically loads the current value.
+	Load() float64
+	// Store atomically stores new as the value.
+	Store(float64)
+	// Swap atomically stores new and returns the previous value.
+	Swap(float64) float64
+}
+
+var (
+	_ AtomicFloat = (*atomicFloatCAS)(nil)
+	_ AtomicFloat = (*atomicFloatCAS2)(nil)
+	_ AtomicFloat = (*atomicFloatMutex)(nil)
+	_ AtomicFloat = (*Number[float64])(nil)
+	_ AtomicFloat = (*atomicFloatKahanCAS)(nil)
+	_ AtomicFloat = (*atomicFloatNeumaier)(nil)
+	_ AtomicFloat = (*atomicFloatSharded)(nil)
+	_ AtomicFloat = (*PaddedAtomicFloatCAS)(nil)
+	_ AtomicFloat = (*atomicFloatBackoffCAS)(nil)
+	_ AtomicFloat = (*atomicFloatPauseCAS)(nil)
+	_ AtomicFloat = (*boundedAtomicFloat)(nil)
+	_ AtomicFloat = (*strictAtomicFloat)(nil)
+	_ AtomicFloat = (*atomicFloatCond)(nil)
+	_ AtomicFloat = (*atomicFloatPtr)(nil)
+	_ AtomicFloat = (*atomicFloatRCU)(nil)
+	_ AtomicFloat = (*atomicFloatInstrumentedCAS)(nil)
+	_ AtomicFloat = (*atomicFloatContextMutex)(nil)
+	_ AtomicFloat = (*adaptiveAtomicFloat)(nil)
+	_ AtomicFloat = (*atomicFloatSeqlock)(nil)
+)
@@ -0,0 +1,1860 @@
+package atomic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+)
+
+// eachImplementation invokes fn with a fresh instance of every core
+// implementation, each initialized to initial.
+func eachImplementation(t *testing.T, initial float64, fn func(t *testing.T, af AtomicFloat)) {
+	t.Helper()
+	t.Run("cas", func(t *testing.T) { fn(t, NewAtomicFloatCAS(initial)) })
+	t.Run("cas2", func(t *testing.T) { fn(t, NewAtomicFloatCAS2(initial)) })
+	t.Run("lock", func(t *testing.T) { fn(t, NewAtomicFloatMutex(initial)) })
+}
+
+// lockFreeImplementations invokes fn with a fresh instance of every core
+// lock-free implementation, each initialized to initial.
+func lockFreeImplementations(t *testing.T, initial float64, fn func(t *testing.T, af AtomicFloat)) {
+	t.Helper()
+	t.Run("cas", func(t *testing.T) { fn(t, NewAtomicFloatCAS(initial)) })
+	t.Run("cas2", func(t *testing.T) { fn(t, NewAtomicFloatCAS2(initial)) })
+}
+
+// parallel runs fn concurrently in count goroutines, passing each its index,
+// and waits for all of them to complete.
+func parallel(count int, fn func(i int)) {
+	var wg sync.WaitGroup
+	wg.Add(count)
+	for i := 0; i < count; i++ {
+		go func(i int) {
+			fn(i)
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	type casFloat interface {
+		AtomicFloat
+		CompareAndSwap(old, new float64) bool
+	}
+
+	t.Run("success", func(t *testing.T) {
+		eachImplementation(t, 5, func(t *testing.T, af AtomicFloat) {
+			var wins int32
+			parallel(100, func(int) {
+				if af.(casFloat).CompareAndSwap(5, 10) {
+					atomic.AddInt32(&wins, 1)
+				}
+			})
+			if got, want := wins, int32(1); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := af.Load(), 10.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		eachImplementation(t, 1, func(t *testing.T, af AtomicFloat) {
+			parallel(100, func(int) {
+				if af.(casFloat).CompareAndSwap(5, 10) {
+					t.Errorf("GOT: true; WANT: false")
+				}
+			})
+			if got, want := af.Load(), 1.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("loop", func(t *testing.T) {
+		eachImplementation(t, 0, func(t *testing.T, af AtomicFloat) {
+			parallel(100, func(int) {
+				for i := 0; i < 100; i++ {
+					for {
+						old := af.Load()
+						if af.(casFloat).CompareAndSwap(old, old+1) {
+							break
+						}
+					}
+				}
+			})
+			if got, want := af.Load(), 10000.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("nan", func(t *testing.T) {
+		eachImplementation(t, math.NaN(), func(t *testing.T, af AtomicFloat) {
+			if af.(casFloat).CompareAndSwap(math.NaN(), 1) {
+				t.Errorf("GOT: true; WANT: false")
+			}
+			if got := af.Load(); !math.IsNaN(got) {
+				t.Errorf("GOT: %v; WANT: NaN", got)
+			}
+		})
+	})
+}
+
+func TestCompareAndSwapEpsilon(t *testing.T) {
+	type casEpsilonFloat interface {
+		AtomicFloat
+		CompareAndSwapEpsilon(old, new, epsilon float64) bool
+	}
+
+	t.Run("within", func(t *testing.T) {
+		eachImplementation(t, 0.30000000000000004, func(t *testing.T, af AtomicFloat) {
+			if !af.(casEpsilonFloat).CompareAndSwapEpsilon(0.3, 1, 1e-9) {
+				t.Errorf("GOT: false; WANT: true")
+			}
+			if got, want := af.Load(), 1.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("outside", func(t *testing.T) {
+		eachImplementation(t, 0.5, func(t *testing.T, af AtomicFloat) {
+			if af.(casEpsilonFloat).CompareAndSwapEpsilon(0.3, 1, 0.1) {
+				t.Errorf("GOT: true; WANT: false")
+			}
+			if got, want := af.Load(), 0.5; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		eachImplementation(t, 0.5, func(t *testing.T, af AtomicFloat) {
+			if !af.(casEpsilonFloat).CompareAndSwapEpsilon(0.3, 1, -0.25) {
+				t.Errorf("GOT: false; WANT: true")
+			}
+		})
+	})
+
+	t.Run("zero", func(t *testing.T) {
+		eachImplementation(t, 0.30000000000000004, func(t *testing.T, af AtomicFloat) {
+			if af.(casEpsilonFloat).CompareAndSwapEpsilon(0.3, 1, 0) {
+				t.Errorf("GOT: true; WANT: false")
+			}
+		})
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		// Adders continuously perturb the value by amounts well within
+		// epsilon, so lock-free implementations must retry their swap
+		// until it lands, and then succeed.
+		const adders, adds, jitter = 8, 10000, 1e-12
+		eachImplementation(t, 0, func(t *testing.T, af AtomicFloat) {
+			var swapped bool
+			parallel(adders+1, func(i int) {
+				if i == adders {
+					swapped = af.(casEpsilonFloat).CompareAndSwapEpsilon(0, 100, 1)
+					return
+				}
+				for j := 0; j < adds; j++ {
+					af.Add(jitter)
+				}
+			})
+			if !swapped {
+				t.Errorf("GOT: false; WANT: true")
+			}
+			if got := af.Load(); got < 100 || got > 100+adders*adds*jitter {
+				t.Errorf("GOT: %v; WANT: 100 plus jitter", got)
+			}
+		})
+	})
+}
+
+func TestSub(t *testing.T) {
+	type subFloat interface {
+		AtomicFloat
+		Sub(delta float64) float64
+	}
+
+	t.Run("round-trip", func(t *testing.T) {
+		eachImplementation(t, 42, func(t *testing.T, af AtomicFloat) {
+			parallel(100, func(i int) {
+				for j := 0; j < 100; j++ {
+					af.Add(float64(i))
+					af.(subFloat).Sub(float64(i))
+				}
+			})
+			if got, want := af.Load(), 42.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("signed-zero", func(t *testing.T) {
+		negativeZero := math.Copysign(0, -1)
+		eachImplementation(t, negativeZero, func(t *testing.T, af AtomicFloat) {
+			got := af.(subFloat).Sub(0)
+			if want := negativeZero - 0; math.Float64bits(got) != math.Float64bits(want) {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+}
+
+func TestMulDiv(t *testing.T) {
+	type mulDivFloat interface {
+		AtomicFloat
+		Mul(factor float64) float64
+		Div(divisor float64) float64
+	}
+
+	t.Run("mul", func(t *testing.T) {
+		eachImplementation(t, 3, func(t *testing.T, af AtomicFloat) {
+			parallel(100, func(int) {
+				for i := 0; i < 10; i++ {
+					af.(mulDivFloat).Mul(2)
+				}
+			})
+			if got, want := af.Load(), 3*math.Pow(2, 1000); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("mul-div", func(t *testing.T) {
+		eachImplementation(t, 3, func(t *testing.T, af AtomicFloat) {
+			parallel(100, func(int) {
+				for i := 0; i < 10; i++ {
+					af.(mulDivFloat).Mul(4)
+					af.(mulDivFloat).Div(4)
+				}
+			})
+			if got, want := af.Load(), 3.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("div-by-zero", func(t *testing.T) {
+		eachImplementation(t, -3, func(t *testing.T, af AtomicFloat) {
+			if got, want := af.(mulDivFloat).Div(0), math.Inf(-1); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+		eachImplementation(t, 0, func(t *testing.T, af AtomicFloat) {
+			if got := af.(mulDivFloat).Div(0); !math.IsNaN(got) {
+				t.Errorf("GOT: %v; WANT: NaN", got)
+			}
+		})
+	})
+}
+
+func TestMaxMin(t *testing.T) {
+	type maxMinFloat interface {
+		AtomicFloat
+		Max(v float64) float64
+		Min(v float64) float64
+	}
+
+	// values returns a deterministic mix of positive and negative samples
+	// for goroutine i.
+	values := func(i int) []float64 {
+		s := make([]float64, 100)
+		for j := range s {
+			s[j] = float64((i*j)%100) * float64(1-2*(j%2)) * float64(j) / 2
+		}
+		return s
+	}
+	wantMax, wantMin := math.Inf(-1), math.Inf(1)
+	for i := 0; i < 100; i++ {
+		for _, v := range values(i) {
+			wantMax = math.Max(wantMax, v)
+			wantMin = math.Min(wantMin, v)
+		}
+	}
+
+	t.Run("max", func(t *testing.T) {
+		eachImplementation(t, math.Inf(-1), func(t *testing.T, af AtomicFloat) {
+			parallel(100, func(i int) {
+				for _, v := range values(i) {
+					af.(maxMinFloat).Max(v)
+				}
+			})
+			if got, want := af.Load(), wantMax; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("min", func(t *testing.T) {
+		eachImplementation(t, math.Inf(1), func(t *testing.T, af AtomicFloat) {
+			parallel(100, func(i int) {
+				for _, v := range values(i) {
+					af.(maxMinFloat).Min(v)
+				}
+			})
+			if got, want := af.Load(), wantMin; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("nan", func(t *testing.T) {
+		eachImplementation(t, 1, func(t *testing.T, af AtomicFloat) {
+			if got := af.(maxMinFloat).Max(math.NaN()); !math.IsNaN(got) {
+				t.Errorf("GOT: %v; WANT: NaN", got)
+			}
+			if got := af.(maxMinFloat).Min(2); !math.IsNaN(got) {
+				t.Errorf("GOT: %v; WANT: NaN", got)
+			}
+		})
+	})
+
+	t.Run("ignore-nan", func(t *testing.T) {
+		type ignoreNaNFloat interface {
+			AtomicFloat
+			MaxIgnoreNaN(v float64) float64
+			MinIgnoreNaN(v float64) float64
+		}
+
+		// withNaNs returns the samples for goroutine i, with NaN scattered
+		// among them.
+		withNaNs := func(i int) []float64 {
+			s := values(i)
+			for j := i % 7; j < len(s); j += 7 {
+				s[j] = math.NaN()
+			}
+			return s
+		}
+		wantMax, wantMin := math.NaN(), math.NaN()
+		for i := 0; i < 100; i++ {
+			for _, v := range withNaNs(i) {
+				if !math.IsNaN(v) {
+					wantMax = maxIgnoreNaN(wantMax, v)
+					wantMin = minIgnoreNaN(wantMin, v)
+				}
+			}
+		}
+
+		eachImplementation(t, math.NaN(), func(t *testing.T, af AtomicFloat) {
+			parallel(100, func(i int) {
+				for _, v := range withNaNs(i) {
+					af.(ignoreNaNFloat).MaxIgnoreNaN(v)
+				}
+			})
+			if got, want := af.Load(), wantMax; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+		eachImplementation(t, math.NaN(), func(t *testing.T, af AtomicFloat) {
+			parallel(100, func(i int) {
+				for _, v := range withNaNs(i) {
+					af.(ignoreNaNFloat).MinIgnoreNaN(v)
+				}
+			})
+			if got, want := af.Load(), wantMin; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+		eachImplementation(t, 1, func(t *testing.T, af AtomicFloat) {
+			if got, want := af.(ignoreNaNFloat).MaxIgnoreNaN(math.NaN()), 1.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := af.(ignoreNaNFloat).MinIgnoreNaN(math.NaN()), 1.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	type updateFloat interface {
+		AtomicFloat
+		Update(fn func(old float64) (new float64)) float64
+	}
+
+	// increment until reaching the ceiling, after which fn returns its
+	// input unchanged.
+	const ceiling = 1000
+	fn := func(old float64) float64 {
+		if old >= ceiling {
+			return old
+		}
+		return old + 1
+	}
+
+	eachImplementation(t, 0, func(t *testing.T, af AtomicFloat) {
+		parallel(100, func(int) {
+			for i := 0; i < 20; i++ {
+				if got := af.(updateFloat).Update(fn); got > ceiling {
+					t.Errorf("GOT: %v; WANT: <= %v", got, ceiling)
+				}
+			}
+		})
+		if got, want := af.Load(), float64(ceiling); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestTryAdd(t *testing.T) {
+	type tryAddFloat interface {
+		AtomicFloat
+		TryAdd(delta float64, maxAttempts int) (float64, bool)
+	}
+
+	t.Run("bounded", func(t *testing.T) {
+		lockFreeImplementations(t, 0, func(t *testing.T, af AtomicFloat) {
+			var successes int64
+			parallel(100, func(int) {
+				for i := 0; i < 100; i++ {
+					if _, ok := af.(tryAddFloat).TryAdd(1, 1); ok {
+						atomic.AddInt64(&successes, 1)
+					}
+				}
+			})
+			// Failed attempts must not have been partially applied.
+			if got, want := af.Load(), float64(successes); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		lockFreeImplementations(t, 0, func(t *testing.T, af AtomicFloat) {
+			parallel(100, func(int) {
+				for i := 0; i < 100; i++ {
+					if _, ok := af.(tryAddFloat).TryAdd(1, 0); !ok {
+						t.Errorf("GOT: false; WANT: true")
+					}
+				}
+			})
+			if got, want := af.Load(), 10000.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+}
+
+func TestLoadAndReset(t *testing.T) {
+	type resetFloat interface {
+		AtomicFloat
+		Reset()
+		LoadAndReset() float64
+	}
+
+	t.Run("reset", func(t *testing.T) {
+		eachImplementation(t, 13, func(t *testing.T, af AtomicFloat) {
+			af.(resetFloat).Reset()
+			if got := af.Load(); math.Float64bits(got) != 0 {
+				t.Errorf("GOT: %v; WANT: 0", got)
+			}
+		})
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		const adders, adds = 10, 1000
+		eachImplementation(t, 0, func(t *testing.T, af AtomicFloat) {
+			var total float64
+			done := make(chan struct{})
+			reaped := make(chan struct{})
+			go func() {
+				for {
+					select {
+					case <-done:
+						close(reaped)
+						return
+					default:
+						total += af.(resetFloat).LoadAndReset()
+					}
+				}
+			}()
+			parallel(adders, func(int) {
+				for i := 0; i < adds; i++ {
+					af.Add(1)
+				}
+			})
+			close(done)
+			<-reaped
+			if got, want := total+af.Load(), float64(adders*adds); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+}
+
+func TestDrainIfAtLeast(t *testing.T) {
+	type drainFloat interface {
+		AtomicFloat
+		DrainIfAtLeast(threshold float64) (float64, bool)
+	}
+
+	t.Run("threshold", func(t *testing.T) {
+		eachImplementation(t, 4, func(t *testing.T, af AtomicFloat) {
+			if got, ok := af.(drainFloat).DrainIfAtLeast(5); got != 4 || ok {
+				t.Errorf("GOT: %v, %v; WANT: 4, false", got, ok)
+			}
+			if got, want := af.Load(), 4.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			af.Add(1)
+			if got, ok := af.(drainFloat).DrainIfAtLeast(5); got != 5 || !ok {
+				t.Errorf("GOT: %v, %v; WANT: 5, true", got, ok)
+			}
+			if got, want := af.Load(), 0.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+		eachImplementation(t, math.NaN(), func(t *testing.T, af AtomicFloat) {
+			if _, ok := af.(drainFloat).DrainIfAtLeast(math.Inf(-1)); ok {
+				t.Errorf("GOT: true; WANT: false")
+			}
+		})
+	})
+
+	t.Run("once", func(t *testing.T) {
+		// Racing drainers may only drain the accumulated value once.
+		eachImplementation(t, 10, func(t *testing.T, af AtomicFloat) {
+			var drains atomic.Int32
+			parallel(100, func(int) {
+				if got, ok := af.(drainFloat).DrainIfAtLeast(10); ok {
+					drains.Add(1)
+					if got != 10 {
+						t.Errorf("GOT: %v; WANT: 10", got)
+					}
+				}
+			})
+			if got, want := drains.Load(), int32(1); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("batches", func(t *testing.T) {
+		// Adders accumulate while a flusher drains whole batches, and no
+		// add is lost.
+		const adders, adds, batch = 10, 1000, 64
+		eachImplementation(t, 0, func(t *testing.T, af AtomicFloat) {
+			var total float64
+			parallel(adders+1, func(i int) {
+				if i == adders {
+					for j := 0; j < adds; j++ {
+						if got, ok := af.(drainFloat).DrainIfAtLeast(batch); ok {
+							if got < batch {
+								t.Errorf("GOT: %v; WANT: at least %v", got, batch)
+							}
+							total += got
+						}
+					}
+					return
+				}
+				for j := 0; j < adds; j++ {
+					af.Add(1)
+				}
+			})
+			if got, want := total+af.Load(), float64(adders*adds); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+}
+
+func TestIncDec(t *testing.T) {
+	type incDecFloat interface {
+		AtomicFloat
+		Inc() float64
+		Dec() float64
+	}
+
+	eachImplementation(t, 0, func(t *testing.T, af AtomicFloat) {
+		parallel(100, func(int) {
+			for i := 0; i < 100; i++ {
+				af.(incDecFloat).Inc()
+				af.(incDecFloat).Inc()
+				af.(incDecFloat).Dec()
+			}
+		})
+		if got, want := af.Load(), 10000.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestFloat32CAS(t *testing.T) {
+	t.Run("rounding", func(t *testing.T) {
+		// float32 accumulation of 0.1 drifts from the exact answer; the
+		// atomic type must drift identically to a plain float32.
+		af := NewAtomicFloat32CAS(0)
+		var want float32
+		for i := 0; i < 100000; i++ {
+			want += 0.1
+			if got := af.Add(0.1); math.Float32bits(got) != math.Float32bits(want) {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		af := NewAtomicFloat32CAS(0)
+		parallel(100, func(int) {
+			for i := 0; i < 100; i++ {
+				af.Add(1)
+			}
+		})
+		if got, want := af.Load(), float32(10000); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("swap", func(t *testing.T) {
+		af := NewAtomicFloat32CAS(1.5)
+		if got, want := af.Swap(2.5), float32(1.5); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		af.Store(3.5)
+		if got, want := af.Load(), float32(3.5); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestNumber(t *testing.T) {
+	t.Run("producer-consumer", func(t *testing.T) {
+		t.Run("float32", func(t *testing.T) {
+			runQ(t, NewNumber[float32](0), 100, 100, 1000)
+		})
+		t.Run("float64", func(t *testing.T) {
+			runQ(t, NewNumber[float64](0), 100, 100, 1000)
+		})
+	})
+
+	t.Run("float32", func(t *testing.T) {
+		n := NewNumber[float32](1.5)
+		if got, want := n.Swap(-2.5), float32(1.5); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if !n.CompareAndSwap(-2.5, 0.1) {
+			t.Errorf("GOT: false; WANT: true")
+		}
+		if n.CompareAndSwap(-2.5, 0.2) {
+			t.Errorf("GOT: true; WANT: false")
+		}
+		var want float32 = 0.1
+		for i := 0; i < 1000; i++ {
+			want += 0.1
+			n.Add(0.1)
+		}
+		if got := n.Load(); math.Float32bits(got) != math.Float32bits(want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("float64", func(t *testing.T) {
+		n := NewNumber(1.5)
+		if got, want := n.Swap(-2.5), 1.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if !n.CompareAndSwap(-2.5, 0.1) {
+			t.Errorf("GOT: false; WANT: true")
+		}
+		if n.CompareAndSwap(math.NaN(), 0.2) {
+			t.Errorf("GOT: true; WANT: false")
+		}
+		n.Store(math.Inf(1))
+		if got, want := n.Load(), math.Inf(1); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestSharded(t *testing.T) {
+	t.Run("producer-consumer", func(t *testing.T) {
+		runQ(t, NewAtomicFloatSharded(0), 100, 100, 1000)
+	})
+
+	t.Run("swap", func(t *testing.T) {
+		const adders, adds = 10, 1000
+		af := NewAtomicFloatSharded(5)
+		var swapped float64
+		parallel(adders+1, func(i int) {
+			if i == adders {
+				for j := 0; j < 100; j++ {
+					swapped += af.Swap(0)
+				}
+				return
+			}
+			for j := 0; j < adds; j++ {
+				af.Add(1)
+			}
+		})
+		// No add may be lost, however it interleaves with the swaps.
+		if got, want := swapped+af.Load(), float64(5+adders*adds); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("concurrent-store", func(t *testing.T) {
+		// Overlapping stores take effect one at a time, so the value is
+		// always one of them, never their sum.
+		af := NewAtomicFloatSharded(0)
+		for i := 0; i < 200; i++ {
+			parallel(4, func(j int) {
+				af.Store(float64(5 + j%2))
+			})
+			if got := af.Load(); got != 5 && got != 6 {
+				t.Fatalf("GOT: %v; WANT: 5 or 6", got)
+			}
+		}
+	})
+
+	t.Run("relaxed", func(t *testing.T) {
+		af := NewAtomicFloatSharded(0.5)
+		parallel(10, func(int) {
+			for i := 0; i < 1000; i++ {
+				af.AddRelaxed(1)
+			}
+		})
+		// Once quiescent, every relaxed add is visible.
+		if got, want := af.Load(), 10000.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("store", func(t *testing.T) {
+		af := NewAtomicFloatSharded(0)
+		parallel(10, func(int) {
+			for i := 0; i < 100; i++ {
+				af.Add(1)
+			}
+		})
+		af.Store(-3)
+		if got, want := af.Load(), -3.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		af.Reset()
+		if got, want := af.Load(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestSeqlock(t *testing.T) {
+	t.Run("producer-consumer", func(t *testing.T) {
+		runQ(t, NewAtomicFloatSeqlock(0), 100, 100, 1000)
+	})
+
+	t.Run("torn", func(t *testing.T) {
+		// Writers alternate between two values that differ in both halves,
+		// so a reader observing part of one write paired with part of the
+		// other would observe neither.
+		a := math.Float64frombits(0x4000000011111111)
+		b := math.Float64frombits(0xc0f0000022222222)
+		af := NewAtomicFloatSeqlock(a)
+		parallel(8, func(i int) {
+			for j := 0; j < 10000; j++ {
+				switch i % 4 {
+				case 0:
+					af.Store(b)
+				case 1:
+					af.Swap(a)
+				default:
+					if got := af.Load(); got != a && got != b {
+						t.Errorf("GOT: %#x; WANT: %#x or %#x", math.Float64bits(got), math.Float64bits(a), math.Float64bits(b))
+						return
+					}
+				}
+			}
+		})
+	})
+}
+
+func TestPaddedAtomicFloatCAS(t *testing.T) {
+	if got, want := unsafe.Sizeof(PaddedAtomicFloatCAS{})%cacheLineSize, uintptr(0); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	runQ(t, NewPaddedAtomicFloatCAS(0), 100, 100, 1000)
+}
+
+func TestBackoffCAS(t *testing.T) {
+	for _, spins := range []int{0, 4} {
+		t.Run(strconv.Itoa(spins), func(t *testing.T) {
+			runQ(t, NewAtomicFloatBackoffCAS(0, spins, 16), 100, 100, 1000)
+		})
+	}
+}
+
+func TestPauseCAS(t *testing.T) {
+	runQ(t, NewAtomicFloatPauseCAS(0), 100, 100, 1000)
+}
+
+func TestCASNonFirstField(t *testing.T) {
+	// On 32-bit platforms such as 386 and arm, 64-bit atomic operations
+	// panic unless their operand is 8-byte aligned, which the compiler only
+	// guarantees for a plain uint64 when it is the first word of an
+	// allocation. Storing the bits in an atomic.Uint64 guarantees alignment
+	// wherever the atomic float is placed.
+	var s struct {
+		b    bool
+		cas  atomicFloatCAS
+		u32  uint32
+		cas2 atomicFloatCAS2
+	}
+	s.cas.Add(1)
+	s.cas2.Add(2)
+	if got, want := s.cas.Load(), 1.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := s.cas2.Load(), 2.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestBits(t *testing.T) {
+	type bitsFloat interface {
+		AtomicFloat
+		Bits() uint64
+	}
+
+	for _, bits := range []uint64{
+		0,
+		1 << 63, // -0
+		math.Float64bits(math.Inf(-1)),
+		0x7ff8000000000001, // quiet NaN with payload
+		0xfff0000000000002, // negative signaling NaN with payload
+		1,                  // smallest subnormal
+	} {
+		t.Run(strconv.FormatUint(bits, 16), func(t *testing.T) {
+			if got, want := NewAtomicFloatCASFromBits(bits).Bits(), bits; got != want {
+				t.Errorf("GOT: %x; WANT: %x", got, want)
+			}
+			eachImplementation(t, math.Float64frombits(bits), func(t *testing.T, af AtomicFloat) {
+				if got, want := af.(bitsFloat).Bits(), bits; got != want {
+					t.Errorf("GOT: %x; WANT: %x", got, want)
+				}
+			})
+		})
+	}
+}
+
+func TestSlice(t *testing.T) {
+	s := NewSlice(4)
+	parallel(8, func(i int) {
+		for j := 0; j < 1000; j++ {
+			s.Add(i%s.Len(), 0.5)
+		}
+	})
+	for i := 0; i < s.Len(); i++ {
+		if got, want := s.Load(i), 1000.0; got != want {
+			t.Errorf("%d: GOT: %v; WANT: %v", i, got, want)
+		}
+	}
+	if got, want := s.Sum(), 4000.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	s.Store(2, -1)
+	if got, want := s.Sum(), 2999.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	for _, i := range []int{-1, 4} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: GOT: no panic; WANT: panic", i)
+				}
+			}()
+			s.Load(i)
+		}()
+	}
+}
+
+func TestClone(t *testing.T) {
+	clone := func(af AtomicFloat) AtomicFloat {
+		switch a := af.(type) {
+		case *atomicFloatCAS:
+			return a.Clone()
+		case *atomicFloatCAS2:
+			return a.Clone()
+		case *atomicFloatMutex:
+			return a.Clone()
+		}
+		panic("unexpected implementation")
+	}
+
+	t.Run("independent", func(t *testing.T) {
+		eachImplementation(t, 3, func(t *testing.T, af AtomicFloat) {
+			c := clone(af)
+			c.Add(1)
+			if got, want := af.Load(), 3.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			af.Add(-1)
+			if got, want := c.Load(), 4.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// Writers only ever add 1, so every clone must observe a whole
+		// number between the initial and final values.
+		eachImplementation(t, 0, func(t *testing.T, af AtomicFloat) {
+			parallel(4, func(i int) {
+				for j := 0; j < 1000; j++ {
+					if i%2 == 0 {
+						af.Add(1)
+						continue
+					}
+					c := clone(af)
+					if v := c.Load(); v != math.Trunc(v) || v < 0 || v > 2000 {
+						t.Errorf("GOT: %v; WANT: whole number in [0, 2000]", v)
+						return
+					}
+					c.Store(-1)
+				}
+			})
+			if got, want := af.Load(), 2000.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+}
+
+func TestAddChecked(t *testing.T) {
+	type checkedFloat interface {
+		AtomicFloat
+		AddChecked(delta float64) (float64, error)
+	}
+
+	cases := []struct {
+		initial, delta float64
+		want           float64
+		err            error
+	}{
+		{1, 2, 3, nil},
+		{math.MaxFloat64, math.MaxFloat64, math.MaxFloat64, ErrInfResult},
+		{1, math.Inf(-1), 1, ErrInfResult},
+		{1, math.NaN(), 1, ErrNaNResult},
+		{math.Inf(1), math.Inf(-1), math.Inf(1), ErrNaNResult},
+		{math.Inf(1), 1, math.Inf(1), nil}, // already infinite
+		{math.NaN(), 1, math.NaN(), nil},   // already NaN
+	}
+	for _, c := range cases {
+		eachImplementation(t, c.initial, func(t *testing.T, af AtomicFloat) {
+			got, err := af.(checkedFloat).AddChecked(c.delta)
+			if !errors.Is(err, c.err) {
+				t.Errorf("%v + %v: GOT: %v; WANT: %v", c.initial, c.delta, err, c.err)
+			}
+			if math.Float64bits(got) != math.Float64bits(c.want) {
+				t.Errorf("%v + %v: GOT: %v; WANT: %v", c.initial, c.delta, got, c.want)
+			}
+			if got, want := af.Load(), c.want; math.Float64bits(got) != math.Float64bits(want) {
+				t.Errorf("%v + %v: GOT: %v; WANT: %v", c.initial, c.delta, got, want)
+			}
+		})
+	}
+}
+
+func TestStrictAtomicFloat(t *testing.T) {
+	for _, initial := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if _, err := NewStrictAtomicFloat(initial, false); !errors.Is(err, ErrNotFinite) {
+			t.Errorf("%v: GOT: %v; WANT: %v", initial, err, ErrNotFinite)
+		}
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		a, err := NewStrictAtomicFloat(1, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Repeated doubling overflows to +Inf after 1024 additions, unless
+		// blocked. Doubling from one goroutine keeps each addition exact.
+		for i := 0; i < 2000; i++ {
+			a.Add(a.Load())
+		}
+		if got, want := a.Load(), 0x1p1023; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		a.Store(math.NaN())
+		if got, want := a.Swap(math.Inf(-1)), 0x1p1023; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := a.Load(), 0x1p1023; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		a, err := NewStrictAtomicFloat(math.MaxFloat64, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("GOT: no panic; WANT: panic")
+				}
+			}()
+			a.Add(math.MaxFloat64)
+		}()
+		if got, want := a.Load(), math.MaxFloat64; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestOnOverflow(t *testing.T) {
+	var calls atomic.Int32
+	var last atomicFloatCAS
+	a := NewAtomicFloatCAS(0)
+	a.OnOverflow(func(oldFinite float64) {
+		calls.Add(1)
+		last.Store(oldFinite)
+	})
+
+	parallel(10, func(int) {
+		a.Add(math.MaxFloat64)
+	})
+	if got, want := a.Load(), math.Inf(1); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := calls.Load(), int32(1); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := last.Load(), math.MaxFloat64; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	// Overflowing again after returning to a finite value calls it again,
+	// but not once the callback is removed.
+	a.Store(-math.MaxFloat64)
+	a.Add(-math.MaxFloat64)
+	if got, want := calls.Load(), int32(2); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	a.OnOverflow(nil)
+	a.Store(math.MaxFloat64)
+	a.Add(math.MaxFloat64)
+	if got, want := calls.Load(), int32(2); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestOnThreshold(t *testing.T) {
+	var rising, falling, other atomic.Int32
+	a := NewAtomicFloatCAS(0)
+	cancel := a.OnThreshold(500, func(new float64, up bool) {
+		if new < 500 == up {
+			t.Errorf("GOT: %v rising %v; WANT: consistent crossing", new, up)
+		}
+		if up {
+			rising.Add(1)
+		} else {
+			falling.Add(1)
+		}
+	})
+	a.OnThreshold(1e6, func(float64, bool) { other.Add(1) })
+
+	// Many concurrent adders cross the threshold exactly once.
+	parallel(100, func(int) {
+		for i := 0; i < 10; i++ {
+			a.Add(1)
+		}
+	})
+	if got, want := rising.Load(), int32(1); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := falling.Load(), int32(0); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	// Oscillating across the threshold fires once per crossing in each
+	// direction.
+	a.Store(499.5)
+	if got, want := falling.Load(), int32(1); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	parallel(10, func(int) {
+		for i := 0; i < 100; i++ {
+			a.AddAndSwap(1)
+			a.Add(-1)
+		}
+	})
+	if got, want := rising.Load(), falling.Load(); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := other.Load(), int32(0); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	cancel()
+	before := rising.Load()
+	a.Add(1)
+	if got, want := rising.Load(), before; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestOnThresholdWrites(t *testing.T) {
+	// Every method that writes the atomic float reports the crossing it
+	// commits, rising from 0 to 2 across a threshold of 1, and falling back.
+	writes := []struct {
+		name       string
+		rise, fall func(a *atomicFloatCAS)
+	}{
+		{"Add", func(a *atomicFloatCAS) { a.Add(2) }, func(a *atomicFloatCAS) { a.Add(-2) }},
+		{"AddAndSwap", func(a *atomicFloatCAS) { a.AddAndSwap(2) }, func(a *atomicFloatCAS) { a.AddAndSwap(-2) }},
+		{"AddChecked", func(a *atomicFloatCAS) { a.AddChecked(2) }, func(a *atomicFloatCAS) { a.AddChecked(-2) }},
+		{"TryAdd", func(a *atomicFloatCAS) { a.TryAdd(2, 1) }, func(a *atomicFloatCAS) { a.TryAdd(-2, 1) }},
+		{"Sub", func(a *atomicFloatCAS) { a.Sub(-2) }, func(a *atomicFloatCAS) { a.Sub(2) }},
+		{"Inc", func(a *atomicFloatCAS) { a.Inc(); a.Inc() }, func(a *atomicFloatCAS) { a.Dec(); a.Dec() }},
+		{"Mul", func(a *atomicFloatCAS) { a.Store(0.5); a.Mul(4) }, func(a *atomicFloatCAS) { a.Mul(0) }},
+		{"Div", func(a *atomicFloatCAS) { a.Store(0.5); a.Div(0.25) }, func(a *atomicFloatCAS) { a.Div(4) }},
+		{"Max", func(a *atomicFloatCAS) { a.Max(2) }, func(a *atomicFloatCAS) { a.Min(0) }},
+		{"MaxIgnoreNaN", func(a *atomicFloatCAS) { a.MaxIgnoreNaN(2) }, func(a *atomicFloatCAS) { a.MinIgnoreNaN(0) }},
+		{"SwapIfGreater", func(a *atomicFloatCAS) { a.SwapIfGreater(2) }, func(a *atomicFloatCAS) { a.SwapIfLess(0) }},
+		{"Update", func(a *atomicFloatCAS) { a.Update(func(float64) float64 { return 2 }) }, func(a *atomicFloatCAS) { a.Update(func(float64) float64 { return 0 }) }},
+		{"Store", func(a *atomicFloatCAS) { a.Store(2) }, func(a *atomicFloatCAS) { a.Store(0) }},
+		{"Swap", func(a *atomicFloatCAS) { a.Swap(2) }, func(a *atomicFloatCAS) { a.Reset() }},
+		{"CompareAndSwap", func(a *atomicFloatCAS) { a.CompareAndSwap(0, 2) }, func(a *atomicFloatCAS) { a.LoadAndReset() }},
+		{"CompareAndSwapEpsilon", func(a *atomicFloatCAS) { a.CompareAndSwapEpsilon(0, 2, 0.5) }, func(a *atomicFloatCAS) { a.DrainIfAtLeast(1) }},
+		{"UnmarshalText", func(a *atomicFloatCAS) { a.UnmarshalText([]byte("2")) }, func(a *atomicFloatCAS) { a.UnmarshalBinary(make([]byte, 8)) }},
+	}
+	for _, w := range writes {
+		var rising, falling int
+		a := NewAtomicFloatCAS(0)
+		a.OnThreshold(1, func(_ float64, up bool) {
+			if up {
+				rising++
+			} else {
+				falling++
+			}
+		})
+		w.rise(a)
+		w.fall(a)
+		if rising != 1 || falling != 1 {
+			t.Errorf("%s: GOT: %d rising, %d falling; WANT: 1 of each", w.name, rising, falling)
+		}
+	}
+}
+
+func TestMemoryOrdering(t *testing.T) {
+	// Message passing: the producer writes plain memory, then publishes with
+	// StoreRelease; once the consumer observes the publication with
+	// LoadAcquire, the plain writes happen before its reads, so the race
+	// detector reports no race, and the reads see the writes.
+	const messages = 1000
+	var payload [messages]int
+	ready := NewAtomicFloatCAS(0)
+	parallel(2, func(i int) {
+		for j := 0; j < messages; j++ {
+			if i == 0 {
+				payload[j] = j * j
+				ready.StoreRelease(float64(j + 1))
+				continue
+			}
+			for ready.LoadAcquire() < float64(j+1) {
+				runtime.Gosched()
+			}
+			if got, want := payload[j], j*j; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+				return
+			}
+		}
+	})
+
+	ready.StoreRelaxed(-2.5)
+	if got, want := ready.LoadRelaxed(), -2.5; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestPointer(t *testing.T) {
+	a := NewAtomicFloatPtr(0.5)
+	parallel(10, func(int) {
+		for i := 0; i < 1000; i++ {
+			a.Add(1)
+		}
+	})
+	if got, want := a.Load(), 10000.5; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := a.Swap(3), 10000.5; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	a.Store(a.Load() * 2)
+	if got, want := a.Load(), 6.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestRCU(t *testing.T) {
+	t.Run("producer-consumer", func(t *testing.T) {
+		runQ(t, NewAtomicFloatRCU(0), 100, 100, 1000)
+	})
+
+	t.Run("readers", func(t *testing.T) {
+		// Writers only ever add 1, so each reader must observe whole
+		// numbers that never decrease.
+		a := NewAtomicFloatRCU(0)
+		parallel(10, func(i int) {
+			var last float64
+			for j := 0; j < 10000; j++ {
+				if i < 2 {
+					a.Add(1)
+					continue
+				}
+				v := a.Load()
+				if v != math.Trunc(v) || v < last {
+					t.Errorf("GOT: %v after %v; WANT: non-decreasing whole number", v, last)
+					return
+				}
+				last = v
+			}
+		})
+		if got, want := a.Load(), 20000.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestComplex128(t *testing.T) {
+	a := NewAtomicComplex128(1 - 1i)
+	parallel(8, func(i int) {
+		for j := 0; j < 1000; j++ {
+			if i%2 == 0 {
+				a.Add(1 + 2i)
+				continue
+			}
+			// Every write preserves imag == 2*real - 3, which a torn read
+			// pairing parts of different writes would violate.
+			if v := a.Load(); imag(v) != 2*real(v)-3 {
+				t.Errorf("GOT: %v; WANT: imag == 2*real - 3", v)
+				return
+			}
+		}
+	})
+	if got, want := a.Load(), 4001+7999i; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := a.Swap(2i), 4001+7999i; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	a.Store(a.Load() * 1i)
+	if got, want := a.Load(), complex(-2, 0); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestNewAtomicFloat(t *testing.T) {
+	cases := []struct {
+		opts []Option
+		want string
+	}{
+		{nil, "*atomic.atomicFloatCAS"},
+		{[]Option{WithExpectedWriters(1)}, "*atomic.atomicFloatCAS"},
+		{[]Option{WithExpectedWriters(7)}, "*atomic.atomicFloatCAS"},
+		{[]Option{WithExpectedWriters(8)}, "*atomic.atomicFloatSharded"},
+		{[]Option{WithExpectedWriters(1000)}, "*atomic.atomicFloatSharded"},
+		{[]Option{WithImplementation(ImplementationCAS2)}, "*atomic.atomicFloatCAS2"},
+		{[]Option{WithImplementation(ImplementationMutex)}, "*atomic.atomicFloatMutex"},
+		{[]Option{WithImplementation(ImplementationSharded)}, "*atomic.atomicFloatSharded"},
+		{[]Option{WithExpectedWriters(1000), WithImplementation(ImplementationCAS)}, "*atomic.atomicFloatCAS"},
+	}
+	for _, c := range cases {
+		af := NewAtomicFloat(1.5, c.opts...)
+		if got, want := fmt.Sprintf("%T", af), c.want; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := af.Load(), 1.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		// Whichever implementation is returned, overlapping stores take
+		// effect one at a time, and overlapping swaps each return a
+		// distinct value that was stored.
+		for i := 0; i < 100; i++ {
+			parallel(4, func(j int) {
+				af.Store(float64(5 + j%2))
+			})
+			if got := af.Load(); got != 5 && got != 6 {
+				t.Fatalf("%s: GOT: %v; WANT: 5 or 6", c.want, got)
+			}
+		}
+		af.Store(0)
+		var returned atomicFloatCAS
+		parallel(8, func(j int) {
+			returned.Add(af.Swap(float64(int(1) << j)))
+		})
+		if got, want := returned.Load()+af.Load(), float64(1<<8-1); got != want {
+			t.Errorf("%s: GOT: %v; WANT: %v", c.want, got, want)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Run("strict", func(t *testing.T) {
+		a := NewAtomicFloatCAS(math.MaxFloat64, WithStrictFinite())
+		if got, want := a.Add(math.MaxFloat64), math.MaxFloat64; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		a.Mul(2)
+		a.Store(math.NaN())
+		if got, want := a.Swap(math.Inf(-1)), math.MaxFloat64; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if a.CompareAndSwap(math.MaxFloat64, math.Inf(1)) {
+			t.Errorf("GOT: swapped; WANT: rejected")
+		}
+		if err := a.UnmarshalText([]byte("NaN")); !errors.Is(err, ErrNotFinite) {
+			t.Errorf("GOT: %v; WANT: %v", err, ErrNotFinite)
+		}
+		if got, want := a.Load(), math.MaxFloat64; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		// Clones remain strict.
+		c := a.Clone()
+		c.Add(math.MaxFloat64)
+		if got, want := c.Load(), math.MaxFloat64; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		// Without the option, the same writes succeed.
+		if got, want := NewAtomicFloatCAS(math.MaxFloat64).Add(math.MaxFloat64), math.Inf(1); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		// TryAdd without a limit of its own gives up after the configured
+		// retries, rather than retrying until it succeeds.
+		a := NewAtomicFloatCAS(0, WithMaxRetries(1))
+		var successes atomic.Int64
+		parallel(100, func(int) {
+			for i := 0; i < 100; i++ {
+				if _, ok := a.TryAdd(1, 0); ok {
+					successes.Add(1)
+				}
+			}
+		})
+		if got, want := a.Load(), float64(successes.Load()); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("compose", func(t *testing.T) {
+		backoff := ExponentialBackoff{Spins: 1, MaxYields: 4}
+		a := NewAtomicFloatCAS(0, WithBackoff(backoff), WithStrictFinite(), WithMaxRetries(3), WithMaxRetries(0))
+		if got, want := *a.opts, (options{backoff: backoff, strictFinite: true}); got != want {
+			t.Errorf("GOT: %+v; WANT: %+v", got, want)
+		}
+		parallel(10, func(int) {
+			for i := 0; i < 1000; i++ {
+				a.Add(1)
+			}
+		})
+		if got, want := a.Load(), 10000.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("NewAtomicFloat", func(t *testing.T) {
+		af := NewAtomicFloat(1, WithExpectedWriters(1000), WithStrictFinite())
+		if got, want := fmt.Sprintf("%T", af), "*atomic.atomicFloatCAS"; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		af.Store(math.NaN())
+		if got, want := af.Load(), 1.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		defer func() {
+			if recover() == nil {
+				t.Errorf("GOT: no panic; WANT: panic")
+			}
+		}()
+		NewAtomicFloat(1, WithImplementation(ImplementationMutex), WithStrictFinite())
+	})
+}
+
+// recordingBackoff is a Backoff that records the attempts it is paused for.
+type recordingBackoff struct {
+	l        sync.Mutex
+	attempts []int
+}
+
+func (b *recordingBackoff) Pause(attempt int) {
+	b.l.Lock()
+	b.attempts = append(b.attempts, attempt)
+	b.l.Unlock()
+}
+
+func TestBackoff(t *testing.T) {
+	t.Run("attempts", func(t *testing.T) {
+		// Interfering with the value from inside Update forces the swap to
+		// fail until the interference stops.
+		b := new(recordingBackoff)
+		a := NewAtomicFloatCAS(0, WithBackoff(b))
+		calls := 0
+		got := a.Update(func(old float64) float64 {
+			if calls++; calls <= 3 {
+				a.Store(old + 1)
+			}
+			return old * 10
+		})
+		if want := 30.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := fmt.Sprint(b.attempts), "[1 2 3]"; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("contention", func(t *testing.T) {
+		b := new(recordingBackoff)
+		a := NewAtomicFloatCAS(0, WithBackoff(b))
+		parallel(10, func(int) {
+			for i := 0; i < 1000; i++ {
+				a.Add(1)
+			}
+		})
+		if got, want := a.Load(), 10000.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		// Each pause beyond the first of a retry loop follows the pause
+		// for the previous attempt, although other goroutines' pauses may
+		// be recorded in between.
+		pending := make(map[int]int)
+		for _, attempt := range b.attempts {
+			if attempt > 1 {
+				if pending[attempt-1] == 0 {
+					t.Fatalf("GOT: attempt %d before %d; WANT: increasing attempts", attempt, attempt-1)
+				}
+				pending[attempt-1]--
+			}
+			pending[attempt]++
+		}
+	})
+
+	t.Run("exponential", func(t *testing.T) {
+		// Only the number of yields varies, so this merely exercises
+		// the boundaries.
+		for _, b := range []Backoff{NoBackoff{}, GoschedBackoff{}, ExponentialBackoff{Spins: 2, MaxYields: 4}, ExponentialBackoff{}} {
+			for attempt := 1; attempt < 100; attempt++ {
+				b.Pause(attempt)
+			}
+		}
+	})
+}
+
+func TestContentionCount(t *testing.T) {
+	a := NewAtomicFloatInstrumentedCAS(0)
+	parallel(10, func(int) {
+		for i := 0; i < 1000; i++ {
+			a.Add(1)
+		}
+	})
+	if got, want := a.Load(), 10000.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	// Interfering with the value from inside Update forces exactly three
+	// failures.
+	a.ResetContention()
+	calls := 0
+	a.Update(func(old float64) float64 {
+		if calls++; calls <= 3 {
+			a.Store(old + 1)
+		}
+		return old
+	})
+	if got, want := a.ContentionCount(), uint64(3); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	a.ResetContention()
+	if got, want := a.ContentionCount(), uint64(0); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestMutexTryAdd(t *testing.T) {
+	a := NewAtomicFloatMutex(1)
+
+	a.l.RLock()
+	if _, ok := a.TryAdd(1); ok {
+		t.Errorf("GOT: true; WANT: false while read locked")
+	}
+	a.l.RUnlock()
+	if got, want := a.Load(), 1.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	// Successful calls are serialized with contending writers, so the
+	// value reflects exactly the successes plus the blocking adds.
+	var successes atomic.Int64
+	parallel(100, func(i int) {
+		for j := 0; j < 100; j++ {
+			if i%2 == 0 {
+				a.Add(1)
+			} else if _, ok := a.TryAdd(1); ok {
+				successes.Add(1)
+			}
+		}
+	})
+	if got, want := a.Load(), 1+5000+float64(successes.Load()); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestAddAndSwap(t *testing.T) {
+	type addAndSwapFloat interface {
+		AtomicFloat
+		AddAndSwap(delta float64) (old, new float64)
+	}
+
+	eachImplementation(t, 5, func(t *testing.T, af AtomicFloat) {
+		const goroutines, adds = 10, 100
+		pairs := make([][2]float64, goroutines*adds)
+		parallel(goroutines, func(i int) {
+			for j := 0; j < adds; j++ {
+				old, new := af.(addAndSwapFloat).AddAndSwap(float64(i + 1))
+				pairs[i*adds+j] = [2]float64{old, new}
+			}
+		})
+
+		// Every add observed the result of exactly one other, so ordering
+		// the pairs by their old values forms an unbroken chain from the
+		// initial value to the final one.
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+		want := 5.0
+		for _, p := range pairs {
+			if p[0] != want {
+				t.Fatalf("GOT: %v; WANT: old value %v", p, want)
+			}
+			want = p[1]
+		}
+		if got := af.Load(); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if want != 5+adds*goroutines*(goroutines+1)/2 {
+			t.Errorf("GOT: %v; WANT: %v", want, 5+adds*goroutines*(goroutines+1)/2)
+		}
+	})
+}
+
+func TestSwapIf(t *testing.T) {
+	// Every goroutine offers every candidate, in an order of its own, so
+	// each step of the ratchet must be won by exactly one caller, and only
+	// the best candidate must remain.
+	const goroutines, candidates = 10, 100
+	run := func(t *testing.T, initial float64, swapIf func(a *atomicFloatCAS, v float64) (float64, bool), candidate func(i int) float64, beats func(prev, v float64) bool) {
+		t.Helper()
+		a := NewAtomicFloatCAS(initial)
+		var wins [candidates]atomic.Int32
+		parallel(goroutines, func(i int) {
+			for j := 0; j < candidates; j++ {
+				k := (i*7 + j) % candidates
+				if prev, swapped := swapIf(a, candidate(k)); swapped {
+					wins[k].Add(1)
+					if !beats(prev, candidate(k)) {
+						t.Errorf("GOT: %v replaced %v; WANT: improvement", candidate(k), prev)
+					}
+				}
+			}
+		})
+		for k := range wins {
+			if got := wins[k].Load(); got > 1 {
+				t.Errorf("%v: GOT: %d winners; WANT: at most 1", candidate(k), got)
+			}
+		}
+		if got, want := wins[candidates-1].Load(), int32(1); got != want {
+			t.Errorf("GOT: %d winners of the best candidate; WANT: %d", got, want)
+		}
+		if got, want := a.Load(), candidate(candidates-1); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	}
+
+	t.Run("greater", func(t *testing.T) {
+		run(t, math.Inf(-1), (*atomicFloatCAS).SwapIfGreater,
+			func(i int) float64 { return float64(i) },
+			func(prev, v float64) bool { return v > prev })
+	})
+	t.Run("less", func(t *testing.T) {
+		run(t, math.Inf(1), (*atomicFloatCAS).SwapIfLess,
+			func(i int) float64 { return -float64(i) },
+			func(prev, v float64) bool { return v < prev })
+	})
+
+	t.Run("nan", func(t *testing.T) {
+		a := NewAtomicFloatCAS(1)
+		if prev, swapped := a.SwapIfGreater(math.NaN()); swapped || prev != 1 {
+			t.Errorf("GOT: %v, %v; WANT: 1, false", prev, swapped)
+		}
+		if _, swapped := a.SwapIfLess(1); swapped {
+			t.Errorf("GOT: true; WANT: false for an equal value")
+		}
+	})
+}
+
+func TestAdaptive(t *testing.T) {
+	t.Run("threshold", func(t *testing.T) {
+		a := NewAtomicFloatAdaptive(2.5, 3)
+		a.contended()
+		a.contended()
+		if a.Promoted() {
+			t.Fatalf("GOT: promoted; WANT: single word below threshold")
+		}
+		a.contended()
+		if !a.Promoted() {
+			t.Fatalf("GOT: single word; WANT: promoted at threshold")
+		}
+		a.contended() // promotion is one-way and happens once
+		if got, want := a.Load(), 2.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := a.Swap(1), 2.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		a.Add(2)
+		if got, want := a.Load(), 3.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("transition", func(t *testing.T) {
+		// Promote part way through a burst of adds, which must all be
+		// preserved, whichever side of the transition they land on.
+		a := NewAtomicFloatAdaptive(0.5, 1)
+		parallel(11, func(i int) {
+			if i == 10 {
+				runtime.Gosched()
+				a.promote()
+				return
+			}
+			for j := 0; j < 1000; j++ {
+				a.Add(1)
+			}
+		})
+		if !a.Promoted() {
+			t.Errorf("GOT: single word; WANT: promoted")
+		}
+		if got, want := a.Load(), 10000.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("concurrent-store", func(t *testing.T) {
+		// Once promoted, stores take effect one at a time, and never leave
+		// behind the sum of several stored values.
+		a := NewAtomicFloatAdaptive(0, 1)
+		a.promote()
+		for i := 0; i < 200; i++ {
+			parallel(4, func(j int) {
+				a.Store(float64(5 + j%2))
+			})
+			if got := a.Load(); got != 5 && got != 6 {
+				t.Fatalf("GOT: %v; WANT: 5 or 6", got)
+			}
+		}
+	})
+
+	t.Run("sentinel", func(t *testing.T) {
+		a := NewAtomicFloatAdaptive(math.Float64frombits(adaptivePromoted), 0)
+		if a.Promoted() {
+			t.Errorf("GOT: promoted; WANT: NaN stored as a quiet NaN")
+		}
+		if got := a.Load(); !math.IsNaN(got) {
+			t.Errorf("GOT: %v; WANT: NaN", got)
+		}
+	})
+}
+
+func TestIntegerAccessors(t *testing.T) {
+	type integerFloat interface {
+		AtomicFloat
+		Int64() int64
+		Round() int64
+	}
+	cases := []struct {
+		value        float64
+		trunc, round int64
+	}{
+		{2.5, 2, 2},
+		{3.5, 3, 4},
+		{-2.5, -2, -2},
+		{-2.7, -2, -3},
+		{math.Copysign(0, -1), 0, 0},
+		{math.NaN(), 0, 0},
+		{math.Inf(1), math.MaxInt64, math.MaxInt64},
+		{math.Inf(-1), math.MinInt64, math.MinInt64},
+		{1 << 63, math.MaxInt64, math.MaxInt64},
+		{math.Nextafter(1<<63, 0), 1<<63 - 1024, 1<<63 - 1024},
+		{-1 << 63, math.MinInt64, math.MinInt64},
+		{math.Nextafter(-1<<63, math.Inf(-1)), math.MinInt64, math.MinInt64},
+		{math.Nextafter(-1<<63, 0), -1<<63 + 1024, -1<<63 + 1024},
+	}
+	eachImplementation(t, 0, func(t *testing.T, af AtomicFloat) {
+		a := af.(integerFloat)
+		for _, c := range cases {
+			a.Store(c.value)
+			if got, want := a.Int64(), c.trunc; got != want {
+				t.Errorf("Int64(%v): GOT: %v; WANT: %v", c.value, got, want)
+			}
+			if got, want := a.Round(), c.round; got != want {
+				t.Errorf("Round(%v): GOT: %v; WANT: %v", c.value, got, want)
+			}
+		}
+	})
+}
+
+func TestConvert(t *testing.T) {
+	conversions := []struct {
+		name    string
+		convert func(AtomicFloat) AtomicFloat
+	}{
+		{"cas", func(af AtomicFloat) AtomicFloat { return ToCAS(af) }},
+		{"cas2", func(af AtomicFloat) AtomicFloat { return ToCAS2(af) }},
+		{"lock", func(af AtomicFloat) AtomicFloat { return ToMutex(af) }},
+		{"sharded", func(af AtomicFloat) AtomicFloat { return ToSharded(af) }},
+	}
+	for _, c := range conversions {
+		t.Run(c.name, func(t *testing.T) {
+			t.Run("independent", func(t *testing.T) {
+				eachImplementation(t, 3, func(t *testing.T, af AtomicFloat) {
+					converted := c.convert(af)
+					converted.Add(1)
+					if got, want := af.Load(), 3.0; got != want {
+						t.Errorf("GOT: %v; WANT: %v", got, want)
+					}
+					af.Add(-1)
+					if got, want := converted.Load(), 4.0; got != want {
+						t.Errorf("GOT: %v; WANT: %v", got, want)
+					}
+				})
+			})
+
+			t.Run("concurrent", func(t *testing.T) {
+				// Writers only ever add 1, so every conversion must
+				// observe a whole number between the initial and final
+				// values.
+				eachImplementation(t, 0, func(t *testing.T, af AtomicFloat) {
+					parallel(4, func(i int) {
+						for j := 0; j < 1000; j++ {
+							if i%2 == 0 {
+								af.Add(1)
+								continue
+							}
+							if v := c.convert(af).Load(); v != math.Trunc(v) || v < 0 || v > 2000 {
+								t.Errorf("GOT: %v; WANT: whole number in [0, 2000]", v)
+								return
+							}
+						}
+					})
+					if got, want := c.convert(af).Load(), 2000.0; got != want {
+						t.Errorf("GOT: %v; WANT: %v", got, want)
+					}
+				})
+			})
+		})
+	}
+}
+
+// TestContract runs the same sequence of operations through the AtomicFloat
+// interface against every implementation that promises exact IEEE 754
+// semantics, requiring bit-for-bit agreement, so that an implementation that
+// drifts from the others is caught.
+func TestContract(t *testing.T) {
+	implementations := []struct {
+		name string
+		new  func(initial float64) AtomicFloat
+	}{
+		{"cas", func(f float64) AtomicFloat { return NewAtomicFloatCAS(f) }},
+		{"cas2", func(f float64) AtomicFloat { return NewAtomicFloatCAS2(f) }},
+		{"lock", func(f float64) AtomicFloat { return NewAtomicFloatMutex(f) }},
+		{"number", func(f float64) AtomicFloat { return NewNumber(f) }},
+		{"padded", func(f float64) AtomicFloat { return NewPaddedAtomicFloatCAS(f) }},
+		{"pause", func(f float64) AtomicFloat { return NewAtomicFloatPauseCAS(f) }},
+		{"backoff", func(f float64) AtomicFloat { return NewAtomicFloatBackoffCAS(f, 4, 16) }},
+		{"ptr", func(f float64) AtomicFloat { return NewAtomicFloatPtr(f) }},
+		{"instrumented", func(f float64) AtomicFloat { return NewAtomicFloatInstrumentedCAS(f) }},
+		{"context", func(f float64) AtomicFloat { return NewAtomicFloatContextMutex(f) }},
+		{"cond", func(f float64) AtomicFloat { return NewAtomicFloatCond(f) }},
+		{"adaptive", func(f float64) AtomicFloat { return NewAtomicFloatAdaptive(f, 0) }},
+		{"seqlock", func(f float64) AtomicFloat { return NewAtomicFloatSeqlock(f) }},
+	}
+
+	const (
+		opStore = iota
+		opAdd
+		opSwap
+	)
+	negativeZero := math.Copysign(0, -1)
+	subnormal := math.SmallestNonzeroFloat64
+	steps := []struct {
+		op     int
+		arg    float64
+		result float64 // returned by Add or Swap
+		want   float64 // loaded afterwards
+	}{
+		{opStore, negativeZero, 0, negativeZero},
+		{opAdd, negativeZero, negativeZero, negativeZero},
+		{opAdd, 0, 0, 0},
+		{opSwap, negativeZero, 0, negativeZero},
+		{opSwap, math.Inf(1), negativeZero, math.Inf(1)},
+		{opAdd, -math.MaxFloat64, math.Inf(1), math.Inf(1)},
+		{opAdd, math.Inf(-1), math.NaN(), math.NaN()},
+		{opSwap, subnormal, math.NaN(), subnormal},
+		{opAdd, subnormal, 2 * subnormal, 2 * subnormal},
+		{opAdd, -3 * subnormal, -subnormal, -subnormal},
+		{opAdd, subnormal, 0, 0},
+		{opStore, math.MaxFloat64, 0, math.MaxFloat64},
+		{opAdd, math.MaxFloat64, math.Inf(1), math.Inf(1)},
+		{opStore, -1, 0, -1},
+		{opAdd, 1, 0, 0},
+	}
+
+	// same returns true when got and want have the same bits, or are both
+	// NaN, since payloads of NaNs produced by arithmetic are unspecified.
+	same := func(got, want float64) bool {
+		return math.Float64bits(got) == math.Float64bits(want) || math.IsNaN(got) && math.IsNaN(want)
+	}
+
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			af := impl.new(1.5)
+			if got, want := af.Load(), 1.5; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			for i, step := range steps {
+				var got float64
+				switch step.op {
+				case opStore:
+					af.Store(step.arg)
+				case opAdd:
+					got = af.Add(step.arg)
+				case opSwap:
+					got = af.Swap(step.arg)
+				}
+				if step.op != opStore && !same(got, step.result) {
+					t.Errorf("step %d: GOT: %v (%#x); WANT: %v (%#x)", i, got, math.Float64bits(got), step.result, math.Float64bits(step.result))
+				}
+				if got := af.Load(); !same(got, step.want) {
+					t.Errorf("step %d: GOT: %v (%#x); WANT: %v (%#x)", i, got, math.Float64bits(got), step.want, math.Float64bits(step.want))
+				}
+			}
+		})
+	}
+}
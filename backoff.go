@@ -0,0 +1,100 @@
+package atomic
+
+import (
+	"math"
+	"runtime"
+	"sync/atomic"
+)
+
+// Backoff is a policy for backing off from a contended compare-and-swap retry
+// loop. Implementations must be safe for concurrent use.
+type Backoff interface {
+	// Pause is called after each failed compare-and-swap operation, with
+	// the number of successive failures so far, starting at 1, and returns
+	// when the loop should retry.
+	Pause(attempt int)
+}
+
+// NoBackoff retries immediately.
+type NoBackoff struct{}
+
+// Pause returns immediately.
+func (NoBackoff) Pause(int) {}
+
+// GoschedBackoff yields the processor to other goroutines once before each
+// retry.
+type GoschedBackoff struct{}
+
+// Pause yields the processor once.
+func (GoschedBackoff) Pause(int) { runtime.Gosched() }
+
+// ExponentialBackoff retries the first Spins failures immediately, after which
+// each failure yields the processor to other goroutines an exponentially
+// increasing number of times, up to MaxYields, before retrying.
+type ExponentialBackoff struct {
+	Spins     int
+	MaxYields int
+}
+
+// Pause yields the processor 2^(attempt-Spins-1) times, up to MaxYields times,
+// once attempt exceeds Spins.
+func (b ExponentialBackoff) Pause(attempt int) {
+	if attempt <= b.Spins || b.MaxYields <= 0 {
+		return
+	}
+	yields := b.MaxYields
+	if shift := attempt - b.Spins - 1; shift < 31 && 1<<shift < yields {
+		yields = 1 << shift
+	}
+	for i := 0; i < yields; i++ {
+		runtime.Gosched()
+	}
+}
+
+// atomicFloatBackoffCAS is an atomic float whose Add retry loop backs off
+// under contention, as described by ExponentialBackoff.
+type atomicFloatBackoffCAS struct {
+	u64     atomic.Uint64
+	backoff ExponentialBackoff
+}
+
+// NewAtomicFloatBackoffCAS returns an atomic float initialized to initial,
+// which retries spins failed compare-and-swap operations before it starts
+// backing off, and never yields more than maxYields times between successive
+// attempts.
+func NewAtomicFloatBackoffCAS(initial float64, spins, maxYields int) *atomicFloatBackoffCAS {
+	a := &atomicFloatBackoffCAS{backoff: ExponentialBackoff{Spins: spins, MaxYields: maxYields}}
+	a.u64.Store(math.Float64bits(initial))
+	return a
+}
+
+// Add attempts to add delta to the value stored in the atomic float and return
+// the new value.
+func (a *atomicFloatBackoffCAS) Add(delta float64) float64 {
+	var newValue float64
+	var oldBits, newBits uint64
+	for failures := 1; ; failures++ {
+		oldBits = a.u64.Load()
+		newValue = math.Float64frombits(oldBits) + delta
+		newBits = math.Float64bits(newValue)
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			return newValue
+		}
+		a.backoff.Pause(failures)
+	}
+}
+
+// Load atomically loads the current atomic float value.
+func (a *atomicFloatBackoffCAS) Load() float64 {
+	return math.Float64frombits(a.u64.Load())
+}
+
+// Store atomically stores new into the atomic float.
+func (a *atomicFloatBackoffCAS) Store(new float64) {
+	a.u64.Store(math.Float64bits(new))
+}
+
+// Swap atomically stores new and returns the previous value.
+func (a *atomicFloatBackoffCAS) Swap(new float64) float64 {
+	return math.Float64frombits(a.u64.Swap(math.Float64bits(new)))
+}
@@ -1,17 +1,24 @@
 package atomic
 
 import (
+	"runtime"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+	"unsafe"
 )
 
-type af64 interface {
-	Add(float64) float64
-	Load() float64
+// producerConsumer is the subset of the methods of an atomic float of either
+// width that runQ and benchmarkProducerConsumer exercise.
+type producerConsumer[T Float] interface {
+	Add(T) T
+	Load() T
+	Store(T)
 }
 
-func runQ(tb testing.TB, af af64, adderCount, loaderCount, operationCount int) {
+func runQ[T Float](tb testing.TB, af producerConsumer[T], adderCount, loaderCount, operationCount int) {
 	tb.Helper()
 	var adderGroup, loaderGroup sync.WaitGroup
 	adderGroup.Add(adderCount)
@@ -30,7 +37,7 @@ func runQ(tb testing.TB, af af64, adderCount, loaderCount, operationCount int) {
 	// spawn loader threads
 	for i := 0; i < loaderCount; i++ {
 		go func() {
-			var sum float64
+			var sum T
 			for i := 0; i < operationCount; i++ {
 				sum += af.Load()
 			}
@@ -42,7 +49,12 @@ func runQ(tb testing.TB, af af64, adderCount, loaderCount, operationCount int) {
 	loaderGroup.Wait() // wait for loaders to complete
 	adderGroup.Wait()  // wait for the adders to complete
 
-	if got, want := af.Load(), 1*float64(adderCount*operationCount); got != want {
+	// float32 only represents consecutive integers exactly up to 2^24.
+	want := 1 * T(adderCount*operationCount)
+	if unsafe.Sizeof(want) == 4 && want > 1<<24 {
+		return
+	}
+	if got := af.Load(); got != want {
 		tb.Errorf("GOT: %v; WANT: %v", got, want)
 	}
 }
@@ -52,30 +64,436 @@ func BenchmarkProducerConsumer(b *testing.B) {
 
 	c := func(b *testing.B, count int) {
 		b.Run(strconv.Itoa(count), func(b *testing.B) {
-			b.Run("cas", func(b *testing.B) {
+			for _, impl := range benchmarkImplementations {
+				b.Run(impl.name, func(b *testing.B) {
+					benchmarkProducerConsumer(b, impl.new(0), count, itemsPerLoader)
+				})
+			}
+		})
+	}
+
+	c(b, 10)
+	c(b, 100)
+	c(b, 1000)
+	c(b, 10000)
+	c(b, 100000)
+}
+
+// benchmarkProducerConsumer runs runQ b.N times on af, which is constructed
+// once, outside the timed loop, so that allocs/op only reports the workload
+// itself.
+func benchmarkProducerConsumer[T Float](b *testing.B, af producerConsumer[T], count, itemsPerLoader int) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		af.Store(0)
+		runQ(b, af, count, count, itemsPerLoader)
+	}
+}
+
+func BenchmarkProducerConsumer32(b *testing.B) {
+	const itemsPerLoader = 1000
+
+	c := func(b *testing.B, count int) {
+		b.Run(strconv.Itoa(count), func(b *testing.B) {
+			b.Run("cas32", func(b *testing.B) {
+				benchmarkProducerConsumer(b, NewAtomicFloat32CAS(0), count, itemsPerLoader)
+			})
+			b.Run("number32", func(b *testing.B) {
+				benchmarkProducerConsumer(b, NewNumber[float32](0), count, itemsPerLoader)
+			})
+		})
+	}
+
+	c(b, 10)
+	c(b, 100)
+	c(b, 1000)
+	c(b, 10000)
+	c(b, 100000)
+}
+
+func BenchmarkTryAdd(b *testing.B) {
+	type tryAdder interface {
+		AtomicFloat
+		TryAdd(float64, int) (float64, bool)
+	}
+
+	// run has count goroutines call TryAdd concurrently, and reports the
+	// fraction of calls that gave up after maxAttempts failed swaps.
+	run := func(b *testing.B, af tryAdder, count, maxAttempts int) {
+		var failures int64
+		var wg sync.WaitGroup
+		wg.Add(count)
+		b.ResetTimer()
+		for i := 0; i < count; i++ {
+			go func() {
+				for i := 0; i < b.N; i++ {
+					if _, ok := af.TryAdd(1, maxAttempts); !ok {
+						atomic.AddInt64(&failures, 1)
+					}
+				}
+				wg.Done()
+			}()
+		}
+		wg.Wait()
+		b.ReportMetric(float64(failures)/float64(b.N*count), "fails/op")
+		if got, want := af.Load(), float64(int64(b.N*count)-failures); got != want {
+			b.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	}
+
+	c := func(b *testing.B, count int) {
+		b.Run(strconv.Itoa(count), func(b *testing.B) {
+			for _, maxAttempts := range []int{1, 4, 16} {
+				b.Run(strconv.Itoa(maxAttempts), func(b *testing.B) {
+					b.Run("cas", func(b *testing.B) {
+						run(b, NewAtomicFloatCAS(0), count, maxAttempts)
+					})
+					b.Run("cas2", func(b *testing.B) {
+						run(b, NewAtomicFloatCAS2(0), count, maxAttempts)
+					})
+				})
+			}
+		})
+	}
+
+	c(b, 10)
+	c(b, 100)
+	c(b, 1000)
+}
+
+func BenchmarkInc(b *testing.B) {
+	type incrementer interface {
+		Add(float64) float64
+		Inc() float64
+	}
+
+	c := func(b *testing.B, name string, af incrementer) {
+		b.Run(name, func(b *testing.B) {
+			b.Run("Inc", func(b *testing.B) {
 				for i := 0; i < b.N; i++ {
-					af := NewAtomicFloatCAS(0)
-					runQ(b, af, count, count, itemsPerLoader)
+					af.Inc()
 				}
 			})
-			b.Run("cas2", func(b *testing.B) {
+			b.Run("Add", func(b *testing.B) {
 				for i := 0; i < b.N; i++ {
-					af := NewAtomicFloatCAS2(0)
-					runQ(b, af, count, count, itemsPerLoader)
+					af.Add(1)
 				}
 			})
-			b.Run("lock", func(b *testing.B) {
+		})
+	}
+
+	c(b, "cas", NewAtomicFloatCAS(0))
+	c(b, "cas2", NewAtomicFloatCAS2(0))
+	c(b, "lock", NewAtomicFloatMutex(0))
+}
+
+func BenchmarkFalseSharing(b *testing.B) {
+	const counters = 8
+
+	// run has one goroutine per counter add to its own counter.
+	run := func(b *testing.B, counter func(i int) AtomicFloat) {
+		var wg sync.WaitGroup
+		wg.Add(counters)
+		for i := 0; i < counters; i++ {
+			go func(af AtomicFloat) {
 				for i := 0; i < b.N; i++ {
-					af := NewAtomicFloatMutex(0)
-					runQ(b, af, count, count, itemsPerLoader)
+					af.Add(1)
+				}
+				wg.Done()
+			}(counter(i))
+		}
+		wg.Wait()
+	}
+
+	b.Run("unpadded", func(b *testing.B) {
+		var a [counters]atomicFloatCAS
+		run(b, func(i int) AtomicFloat { return &a[i] })
+	})
+	b.Run("padded", func(b *testing.B) {
+		var a [counters]PaddedAtomicFloatCAS
+		run(b, func(i int) AtomicFloat { return &a[i] })
+	})
+}
+
+func BenchmarkSlice(b *testing.B) {
+	const shards = 8
+
+	b.Run("Add", func(b *testing.B) {
+		s := NewSlice(shards)
+		var wg sync.WaitGroup
+		wg.Add(shards)
+		for i := 0; i < shards; i++ {
+			go func(i int) {
+				for j := 0; j < b.N; j++ {
+					s.Add(i, 1)
+				}
+				wg.Done()
+			}(i)
+		}
+		wg.Wait()
+		if got, want := s.Sum(), float64(b.N*shards); got != want {
+			b.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	b.Run("Sum", func(b *testing.B) {
+		s := NewSlice(shards)
+		for i := 0; i < b.N; i++ {
+			s.Sum()
+		}
+	})
+}
+
+func BenchmarkPointer(b *testing.B) {
+	// Both perform the same compare-and-swap loop, so the difference is the
+	// cost of allocating a new value for every attempt.
+	c := func(b *testing.B, name string, af AtomicFloat) {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				af.Add(1)
+			}
+		})
+	}
+
+	c(b, "cas", NewAtomicFloatCAS(0))
+	c(b, "ptr", NewAtomicFloatPtr(0))
+}
+
+func BenchmarkReadMostly(b *testing.B) {
+	// Parallel readers of a value that a single background writer updates
+	// rarely, as for configuration read on every request. The RCU
+	// implementation's Load is a single pointer load, whereas the mutex
+	// implementation's readers contend on its RWMutex reader count.
+	c := func(b *testing.B, name string, af AtomicFloat) {
+		b.Run(name, func(b *testing.B) {
+			done := make(chan struct{})
+			stopped := make(chan struct{})
+			go func() {
+				defer close(stopped)
+				for {
+					select {
+					case <-done:
+						return
+					case <-time.After(time.Millisecond):
+						af.Add(1)
+					}
+				}
+			}()
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					af.Load()
 				}
 			})
+			b.StopTimer()
+			close(done)
+			<-stopped
 		})
 	}
 
-	c(b, 10)
-	c(b, 100)
-	c(b, 1000)
-	c(b, 10000)
-	c(b, 100000)
+	c(b, "lock", NewAtomicFloatMutex(0))
+	c(b, "seqlock", NewAtomicFloatSeqlock(0))
+	c(b, "rcu", NewAtomicFloatRCU(0))
+}
+
+func BenchmarkAddRelaxed(b *testing.B) {
+	// Add on the sharded implementation folds every shard to return the new
+	// value, which AddRelaxed avoids.
+	c := func(b *testing.B, name string, add func()) {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					add()
+				}
+			})
+		})
+	}
+
+	cas := NewAtomicFloatCAS(0)
+	sharded := NewAtomicFloatSharded(0)
+	c(b, "cas", func() { cas.Add(1) })
+	c(b, "sharded", func() { sharded.Add(1) })
+	c(b, "relaxed", func() { sharded.AddRelaxed(1) })
+}
+
+func BenchmarkBackoff(b *testing.B) {
+	const adders = 8
+
+	c := func(b *testing.B, name string, backoff Backoff) {
+		b.Run(name, func(b *testing.B) {
+			af := NewAtomicFloatCAS(0, WithBackoff(backoff))
+			var wg sync.WaitGroup
+			wg.Add(adders)
+			for i := 0; i < adders; i++ {
+				go func() {
+					for i := 0; i < b.N; i++ {
+						af.Add(1)
+					}
+					wg.Done()
+				}()
+			}
+			wg.Wait()
+		})
+	}
+
+	c(b, "none", NoBackoff{})
+	c(b, "gosched", GoschedBackoff{})
+	c(b, "exponential", ExponentialBackoff{Spins: 4, MaxYields: 16})
+}
+
+func BenchmarkContention(b *testing.B) {
+	// Each run reports the failed compare-and-swap operations per Add, which
+	// rise with the number of adders when they run in parallel.
+	for _, adders := range []int{1, 2, 4, 8, 16} {
+		b.Run(strconv.Itoa(adders), func(b *testing.B) {
+			af := NewAtomicFloatInstrumentedCAS(0)
+			var wg sync.WaitGroup
+			wg.Add(adders)
+			for i := 0; i < adders; i++ {
+				go func() {
+					for i := 0; i < b.N; i++ {
+						af.Add(1)
+					}
+					wg.Done()
+				}()
+			}
+			wg.Wait()
+			b.ReportMetric(float64(af.ContentionCount())/float64(b.N*adders), "fails/op")
+		})
+	}
+}
+
+// benchmarkImplementations are the AtomicFloat implementations compared by
+// the benchmark matrices.
+var benchmarkImplementations = []struct {
+	name string
+	new  func(initial float64) AtomicFloat
+}{
+	{"cas", func(f float64) AtomicFloat { return NewAtomicFloatCAS(f) }},
+	{"cas2", func(f float64) AtomicFloat { return NewAtomicFloatCAS2(f) }},
+	{"lock", func(f float64) AtomicFloat { return NewAtomicFloatMutex(f) }},
+	{"pause", func(f float64) AtomicFloat { return NewAtomicFloatPauseCAS(f) }},
+	{"backoff", func(f float64) AtomicFloat { return NewAtomicFloatBackoffCAS(f, 4, 16) }},
+	{"number", func(f float64) AtomicFloat { return NewNumber(f) }},
+	{"sharded", func(f float64) AtomicFloat { return NewAtomicFloatSharded(f) }},
+	{"ptr", func(f float64) AtomicFloat { return NewAtomicFloatPtr(f) }},
+	{"adaptive", func(f float64) AtomicFloat { return NewAtomicFloatAdaptive(f, 0) }},
+	{"seqlock", func(f float64) AtomicFloat { return NewAtomicFloatSeqlock(f) }},
+}
+
+func BenchmarkReadWriteRatio(b *testing.B) {
+	const itemsPerGoroutine = 1000
+
+	ratios := []struct{ readers, writers int }{
+		{1, 1},
+		{10, 1},
+		{100, 1},
+		{1, 10},
+	}
+	for _, r := range ratios {
+		b.Run(strconv.Itoa(r.readers)+":"+strconv.Itoa(r.writers), func(b *testing.B) {
+			for _, impl := range benchmarkImplementations {
+				b.Run(impl.name, func(b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						runQ(b, impl.new(0), r.writers, r.readers, itemsPerGoroutine)
+					}
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkGOMAXPROCS(b *testing.B) {
+	const count, itemsPerLoader = 100, 1000
+
+	procs := []int{1, 2, 4, 8}
+	if n := runtime.NumCPU(); n > procs[len(procs)-1] {
+		procs = append(procs, n)
+	}
+	for _, p := range procs {
+		b.Run(strconv.Itoa(p), func(b *testing.B) {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(p))
+			for _, impl := range benchmarkImplementations {
+				b.Run(impl.name, func(b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						runQ(b, impl.new(0), count, count, itemsPerLoader)
+					}
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkOperations(b *testing.B) {
+	for _, impl := range benchmarkImplementations {
+		b.Run(impl.name, func(b *testing.B) {
+			af := impl.new(0)
+			b.Run("Add", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					af.Add(1)
+				}
+			})
+			b.Run("Load", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					af.Load()
+				}
+			})
+			b.Run("Store", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					af.Store(1)
+				}
+			})
+			b.Run("Swap", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					af.Swap(1)
+				}
+			})
+		})
+	}
+}
+
+// benchmarkCoreOp measures op on each core implementation, both from a single
+// goroutine and from GOMAXPROCS goroutines at once.
+func benchmarkCoreOp(b *testing.B, op func(af AtomicFloat)) {
+	for _, impl := range benchmarkImplementations[:3] { // cas, cas2, lock
+		b.Run(impl.name, func(b *testing.B) {
+			af := impl.new(0)
+			b.Run("serial", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					op(af)
+				}
+			})
+			b.Run("parallel", func(b *testing.B) {
+				b.ReportAllocs()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						op(af)
+					}
+				})
+			})
+		})
+	}
+}
+
+func BenchmarkLoad(b *testing.B) {
+	benchmarkCoreOp(b, func(af AtomicFloat) { af.Load() })
+}
+
+func BenchmarkStore(b *testing.B) {
+	benchmarkCoreOp(b, func(af AtomicFloat) { af.Store(1) })
+}
+
+// BenchmarkSwap compares the single atomic exchange of the compare-and-swap
+// implementations with the lock and unlock of the mutex implementation, such
+// as when double buffering.
+func BenchmarkSwap(b *testing.B) {
+	benchmarkCoreOp(b, func(af AtomicFloat) { af.Swap(1) })
 }
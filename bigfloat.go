@@ -0,0 +1,60 @@
+package atomic
+
+import (
+	"math/big"
+	"sync"
+)
+
+// atomicBigFloat is an arbitrary-precision atomic accumulator, such as for
+// financial totals that must not lose the low-order digits a float64 would.
+// big.Float is not safe for concurrent use, so every access to it is guarded
+// by a read-write mutex, and it never escapes: values are always copied in and
+// out.
+type atomicBigFloat struct {
+	f *big.Float
+	l sync.RWMutex
+}
+
+// NewAtomicBigFloat returns an atomic big float initialized to initial, which
+// rounds results of arithmetic to prec bits of mantissa. A prec of 0 selects
+// 256 bits. As with big.Float, it panics with a big.ErrNaN when initial is NaN.
+func NewAtomicBigFloat(initial float64, prec uint) *atomicBigFloat {
+	if prec == 0 {
+		prec = 256
+	}
+	return &atomicBigFloat{f: new(big.Float).SetPrec(prec).SetFloat64(initial)}
+}
+
+// Add atomically adds delta to the value stored in the atomic big float. As
+// with big.Float, it panics with a big.ErrNaN when adding infinities of
+// opposite sign.
+func (a *atomicBigFloat) Add(delta *big.Float) {
+	a.l.Lock()
+	defer a.l.Unlock()
+	a.f.Add(a.f, delta)
+}
+
+// AddFloat64 atomically adds delta to the value stored in the atomic big
+// float. It panics with a big.ErrNaN when delta is NaN, or when adding
+// infinities of opposite sign.
+func (a *atomicBigFloat) AddFloat64(delta float64) {
+	a.Add(big.NewFloat(delta))
+}
+
+// Load atomically loads a copy of the current value, which the caller may
+// modify freely.
+func (a *atomicBigFloat) Load() *big.Float {
+	a.l.RLock()
+	f := new(big.Float).Copy(a.f)
+	a.l.RUnlock()
+	return f
+}
+
+// Text atomically formats the current value, as big.Float.Text does, according
+// to format and prec.
+func (a *atomicBigFloat) Text(format byte, prec int) string {
+	a.l.RLock()
+	s := a.f.Text(format, prec)
+	a.l.RUnlock()
+	return s
+}
@@ -0,0 +1,124 @@
+package atomic
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+)
+
+// boundedAtomicFloat is an atomic float whose value saturates at fixed lower
+// and upper bounds rather than overshooting them. Its value is never NaN:
+// writes that would store NaN are ignored.
+type boundedAtomicFloat struct {
+	u64      atomic.Uint64
+	min, max float64
+}
+
+// NewBoundedAtomicFloat returns an atomic float whose value is always clamped
+// to the closed interval [min, max], initialized to initial clamped to that
+// interval, or to min when initial is NaN. When min is greater than max, the
+// two bounds are swapped. It panics when either bound is NaN.
+func NewBoundedAtomicFloat(initial, min, max float64) *boundedAtomicFloat {
+	if math.IsNaN(min) || math.IsNaN(max) {
+		panic(fmt.Sprintf("atomic: NewBoundedAtomicFloat bounds must not be NaN: %v, %v", min, max))
+	}
+	if min > max {
+		min, max = max, min
+	}
+	a := &boundedAtomicFloat{min: min, max: max}
+	if math.IsNaN(initial) {
+		initial = min
+	}
+	a.u64.Store(math.Float64bits(a.clamp(initial)))
+	return a
+}
+
+// clamp returns f limited to the bounds of the atomic float. NaN is returned
+// unchanged, for callers to ignore.
+func (a *boundedAtomicFloat) clamp(f float64) float64 {
+	if f < a.min {
+		return a.min
+	}
+	if f > a.max {
+		return a.max
+	}
+	return f
+}
+
+// Bounds returns the lower and upper bounds of the atomic float.
+func (a *boundedAtomicFloat) Bounds() (min, max float64) {
+	return a.min, a.max
+}
+
+// Add attempts to add delta to the value stored in the atomic float, clamping
+// the sum to the bounds, and return the new value. When the sum is NaN, such as
+// when delta is NaN, the value is left unchanged and returned.
+func (a *boundedAtomicFloat) Add(delta float64) float64 {
+	var oldValue, newValue float64
+	var oldBits, newBits uint64
+	for {
+		oldBits = a.u64.Load()
+		oldValue = math.Float64frombits(oldBits)
+		if newValue = a.clamp(oldValue + delta); newValue != newValue {
+			return oldValue // NaN
+		}
+		newBits = math.Float64bits(newValue)
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			return newValue
+		}
+	}
+}
+
+// Load atomically loads the current atomic float value.
+func (a *boundedAtomicFloat) Load() float64 {
+	return math.Float64frombits(a.u64.Load())
+}
+
+// Store atomically stores new, clamped to the bounds, into the atomic float. A
+// NaN new is ignored.
+func (a *boundedAtomicFloat) Store(new float64) {
+	if new != new {
+		return // NaN
+	}
+	a.u64.Store(math.Float64bits(a.clamp(new)))
+}
+
+// Swap atomically stores new, clamped to the bounds, and returns the previous
+// value. A NaN new is ignored, and the current value returned.
+func (a *boundedAtomicFloat) Swap(new float64) float64 {
+	if new != new {
+		return a.Load() // NaN
+	}
+	return math.Float64frombits(a.u64.Swap(math.Float64bits(a.clamp(new))))
+}
+
+// AddWithCap attempts to add as much of delta to the value stored in the atomic
+// float as fits under cap, or the upper bound when that is lower, and returns
+// the amount applied along with the remainder that did not fit. Concurrent
+// adders racing toward the cap split the headroom between them, and never
+// exceed it. When the value is already at or above the cap, or delta is not
+// positive, nothing is applied, and all of delta is returned as the
+// remainder.
+func (a *boundedAtomicFloat) AddWithCap(delta, cap float64) (applied, remainder float64) {
+	limit := math.Min(cap, a.max)
+	for {
+		oldBits := a.u64.Load()
+		oldValue := math.Float64frombits(oldBits)
+		if !(delta > 0) || !(oldValue < limit) {
+			return 0, delta
+		}
+		newValue := oldValue + delta
+		if newValue <= limit {
+			// All of delta fits, so report it exactly, rather than the
+			// rounded difference between the new and old values.
+			if a.u64.CompareAndSwap(oldBits, math.Float64bits(newValue)) {
+				return delta, 0
+			}
+			continue
+		}
+		if a.u64.CompareAndSwap(oldBits, math.Float64bits(limit)) {
+			applied = limit - oldValue
+			return applied, delta - applied
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBoundedAtomicFloat(t *testing.T) {
+	t.Run("clamp", func(t *testing.T) {
+		a := NewBoundedAtomicFloat(2, 0, 1)
+		if got, want := a.Load(), 1.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := a.Add(-5), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := a.Add(0.25), 0.25; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := a.Swap(3), 0.25; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := a.Load(), 1.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		a.Store(-3)
+		if got, want := a.Load(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("swapped-bounds", func(t *testing.T) {
+		a := NewBoundedAtomicFloat(0.5, 1, 0)
+		min, max := a.Bounds()
+		if min != 0 || max != 1 {
+			t.Errorf("GOT: [%v, %v]; WANT: [0, 1]", min, max)
+		}
+	})
+
+	t.Run("nan", func(t *testing.T) {
+		for _, bounds := range [][2]float64{{math.NaN(), 1}, {0, math.NaN()}} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%v: GOT: no panic; WANT: panic", bounds)
+					}
+				}()
+				NewBoundedAtomicFloat(0.5, bounds[0], bounds[1])
+			}()
+		}
+		if got, want := NewBoundedAtomicFloat(math.NaN(), 0, 1).Load(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		a := NewBoundedAtomicFloat(0.5, math.Inf(-1), math.Inf(1))
+		if got, want := a.Add(math.NaN()), 0.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		a.Store(math.NaN())
+		if got, want := a.Swap(math.NaN()), 0.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		a.Store(math.Inf(1))
+		if got, want := a.Add(math.Inf(-1)), math.Inf(1); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		a := NewBoundedAtomicFloat(0.5, 0, 1)
+		parallel(100, func(i int) {
+			delta := 0.125
+			if i%2 == 0 {
+				delta = -delta
+			}
+			for j := 0; j < 100; j++ {
+				if got := a.Add(delta); got < 0 || got > 1 {
+					t.Errorf("GOT: %v; WANT: within [0, 1]", got)
+				}
+				if got := a.Load(); got < 0 || got > 1 {
+					t.Errorf("GOT: %v; WANT: within [0, 1]", got)
+				}
+			}
+		})
+	})
+}
+
+func TestAddWithCap(t *testing.T) {
+	t.Run("exact-fit", func(t *testing.T) {
+		a := NewBoundedAtomicFloat(5, 0, 100)
+		if applied, remainder := a.AddWithCap(5, 10); applied != 5 || remainder != 0 {
+			t.Errorf("GOT: %v, %v; WANT: 5, 0", applied, remainder)
+		}
+		if applied, remainder := a.AddWithCap(0.5, 10); applied != 0 || remainder != 0.5 {
+			t.Errorf("GOT: %v, %v; WANT: 0, 0.5", applied, remainder)
+		}
+		if got, want := a.Load(), 10.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("inexact", func(t *testing.T) {
+		// 0.1 + 0.2 rounds, but when all of delta fits, all of it is
+		// reported as applied, with no remainder.
+		old, delta := 0.1, 0.2
+		a := NewBoundedAtomicFloat(old, 0, 1)
+		if applied, remainder := a.AddWithCap(delta, 1); applied != delta || remainder != 0 {
+			t.Errorf("GOT: %v, %v; WANT: %v, 0", applied, remainder, delta)
+		}
+		if got, want := a.Load(), old+delta; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		a := NewBoundedAtomicFloat(7, 0, 8) // the upper bound is lower than cap
+		if applied, remainder := a.AddWithCap(3, 10); applied != 1 || remainder != 2 {
+			t.Errorf("GOT: %v, %v; WANT: 1, 2", applied, remainder)
+		}
+		if applied, remainder := a.AddWithCap(-3, 10); applied != 0 || remainder != -3 {
+			t.Errorf("GOT: %v, %v; WANT: 0, -3", applied, remainder)
+		}
+	})
+
+	t.Run("contended", func(t *testing.T) {
+		// Racing adders split exactly the available headroom.
+		const adders, delta, headroom = 100, 4, 250
+		a := NewBoundedAtomicFloat(0, 0, 1000)
+		var applied, remainder atomicFloatCAS
+		parallel(adders, func(int) {
+			ap, rem := a.AddWithCap(delta, headroom)
+			applied.Add(ap)
+			remainder.Add(rem)
+		})
+		if got, want := a.Load(), float64(headroom); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := applied.Load(), float64(headroom); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := remainder.Load(), float64(adders*delta-headroom); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestCounter(t *testing.T) {
+	t.Run("floor", func(t *testing.T) {
+		c := NewCounter(-3)
+		if got, want := c.Load(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		c.Add(2.5)
+		if got, want := c.Sub(4), 2.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := c.Dec(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := c.Load(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("nan", func(t *testing.T) {
+		if got, want := NewCounter(math.NaN()).Load(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		c := NewCounter(3)
+		if got, want := c.Add(math.NaN()), 3.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := c.Sub(math.NaN()), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := c.Inc(), 4.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// Twice as many decrements as increments would drive a naive
+		// counter to -5000.
+		c := NewCounter(0)
+		var incs, removed uint64
+		parallel(100, func(i int) {
+			for j := 0; j < 50; j++ {
+				if i%3 == 0 {
+					c.Inc()
+					atomic.AddUint64(&incs, 1)
+				} else if got := c.Dec(); got < 0 {
+					t.Errorf("GOT: %v; WANT: >= 0", got)
+				} else {
+					atomic.AddUint64(&removed, uint64(got))
+				}
+				if got := c.Load(); got < 0 {
+					t.Errorf("GOT: %v; WANT: >= 0", got)
+				}
+			}
+		})
+		if got, want := c.Load(), float64(incs-removed); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestBufferedCounter(t *testing.T) {
+	c := NewBufferedCounter(0.5)
+	parallel(10, func(int) {
+		h := c.Handle()
+		for i := 1; i <= 1000; i++ {
+			h.Add(1)
+			if i%64 == 0 {
+				h.Flush()
+			}
+		}
+		if got, want := h.Pending(), float64(1000%64); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		h.Flush()
+		if got, want := h.Pending(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+	if got, want := c.Load(), 10000.5; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	t.Run("lag", func(t *testing.T) {
+		c := NewBufferedCounter(0)
+		h := c.Handle()
+		h.Add(3)
+		if got, want := c.Load(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := h.Flush(), 3.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := h.Flush(), 3.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestQuota(t *testing.T) {
+	q := NewQuota(10, 15)
+	if !q.Spend(4) {
+		t.Errorf("GOT: false; WANT: true")
+	}
+	if q.Spend(7) {
+		t.Errorf("GOT: true; WANT: false")
+	}
+	if got, want := q.Load(), 6.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := q.SpendUpTo(8), 6.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := q.Refill(10), 10.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := q.Refill(10), 5.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := q.Load(), q.Cap(); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := NewQuota(-1, 2).Load(), 0.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	t.Run("nan", func(t *testing.T) {
+		if got, want := NewQuota(math.NaN(), 2).Load(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		q := NewQuota(5, 10)
+		if q.Spend(math.NaN()) {
+			t.Errorf("GOT: true; WANT: false")
+		}
+		if got, want := q.SpendUpTo(math.NaN()), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := q.Refill(math.NaN()), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := q.Load(), 5.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// The balance stays within [0, cap], and everything spent was
+		// either initially available or refilled.
+		const capacity = 100
+		q := NewQuota(50, capacity)
+		var spent, refilled atomicFloatCAS
+		parallel(20, func(i int) {
+			for j := 0; j < 1000; j++ {
+				switch i % 3 {
+				case 0:
+					if q.Spend(3) {
+						spent.Add(3)
+					}
+				case 1:
+					spent.Add(q.SpendUpTo(2))
+				default:
+					refilled.Add(q.Refill(4))
+				}
+				if got := q.Load(); got < 0 || got > capacity {
+					t.Errorf("GOT: %v; WANT: within [0, %v]", got, capacity)
+					return
+				}
+			}
+		})
+		if got, want := q.Load(), 50+refilled.Load()-spent.Load(); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package atomic
+
+// BufferedCounter is an atomic float accumulator for extremely hot counters,
+// where each goroutine adds to its own CounterHandle, which touches no shared
+// memory, and periodically flushes its buffered amount into the shared total.
+// Load only includes amounts that have been flushed, so it lags behind any
+// amounts still buffered in handles.
+type BufferedCounter struct {
+	total atomicFloatCAS
+}
+
+// NewBufferedCounter returns a BufferedCounter initialized to initial.
+func NewBufferedCounter(initial float64) *BufferedCounter {
+	c := new(BufferedCounter)
+	c.total.Store(initial)
+	return c
+}
+
+// Handle returns a new CounterHandle that buffers adds to the counter.
+func (c *BufferedCounter) Handle() *CounterHandle {
+	return &CounterHandle{counter: c}
+}
+
+// Load atomically loads the sum of the initial value and every amount flushed
+// so far, which excludes amounts still buffered in handles.
+func (c *BufferedCounter) Load() float64 {
+	return c.total.Load()
+}
+
+// CounterHandle is a goroutine-local buffer for a BufferedCounter. A handle is
+// not safe for concurrent use; each goroutine should obtain its own handle
+// from BufferedCounter.Handle.
+type CounterHandle struct {
+	counter *BufferedCounter
+	pending float64
+}
+
+// Add adds delta to the amount buffered in the handle without touching the
+// shared total.
+func (h *CounterHandle) Add(delta float64) {
+	h.pending += delta
+}
+
+// Pending returns the amount buffered in the handle that has not yet been
+// flushed.
+func (h *CounterHandle) Pending() float64 {
+	return h.pending
+}
+
+// Flush atomically adds the buffered amount to the shared total, resets the
+// buffer, and returns the new total. It does not touch shared memory when
+// nothing is buffered.
+func (h *CounterHandle) Flush() float64 {
+	if h.pending == 0 {
+		return h.counter.Load()
+	}
+	total := h.counter.total.Add(h.pending)
+	h.pending = 0
+	return total
+}
@@ -1,14 +1,59 @@
 package atomic
 
 import (
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
 	"math"
 	"sync/atomic"
 )
 
-type atomicFloatCAS struct{ u64 uint64 }
+type atomicFloatCAS struct {
+	u64        atomic.Uint64
+	onOverflow atomic.Pointer[func(oldFinite float64)]
+	thresholds atomic.Pointer[[]*thresholdCallback] // nil until OnThreshold
+	opts       *options                             // nil unless constructed with options
+	format     textFormat
+}
+
+// NewAtomicFloatCAS returns an atomic float initialized to initial, configured
+// by opts. WithMaxRetries, WithBackoff, and WithStrictFinite apply; the
+// options that select an implementation have no effect.
+func NewAtomicFloatCAS(initial float64, opts ...Option) *atomicFloatCAS {
+	a := new(atomicFloatCAS)
+	if len(opts) > 0 {
+		a.opts = newOptions(opts)
+	}
+	if a.rejects(initial) {
+		panic(fmt.Sprintf("atomic: NewAtomicFloatCAS strict initial value must be finite: %v", initial))
+	}
+	a.u64.Store(math.Float64bits(initial))
+	return a
+}
+
+// NewAtomicFloatCASFromBits returns an atomic float initialized to the value
+// whose IEEE 754 binary representation is bits. Unlike converting bits to a
+// float64 first, this preserves every bit pattern exactly, including NaN
+// payloads.
+func NewAtomicFloatCASFromBits(bits uint64) *atomicFloatCAS {
+	a := new(atomicFloatCAS)
+	a.u64.Store(bits)
+	return a
+}
 
-func NewAtomicFloatCAS(initial float64) *atomicFloatCAS {
-	return &atomicFloatCAS{u64: math.Float64bits(initial)}
+// rejects returns true when the atomic float was constructed WithStrictFinite
+// and v is not finite, in which case the write of v must not take place.
+func (a *atomicFloatCAS) rejects(v float64) bool {
+	return a.opts != nil && a.opts.strictFinite && (math.IsNaN(v) || math.IsInf(v, 0))
+}
+
+// backoff pauses after failures successive failed compare-and-swap operations,
+// when the atomic float was constructed WithBackoff.
+func (a *atomicFloatCAS) backoff(failures int) {
+	if a.opts != nil && a.opts.backoff != nil {
+		a.opts.backoff.Pause(failures)
+	}
 }
 
 // Add attempts to add delta to the value stored in the atomic float and return
@@ -16,27 +61,644 @@ func NewAtomicFloatCAS(initial float64) *atomicFloatCAS {
 func (a *atomicFloatCAS) Add(delta float64) float64 {
 	var newValue float64
 	var oldBits, newBits uint64
-	for {
-		oldBits = atomic.LoadUint64(&a.u64)
+	for failures := 1; ; failures++ {
+		oldBits = a.u64.Load()
 		newValue = math.Float64frombits(oldBits) + delta
+		if a.rejects(newValue) {
+			return math.Float64frombits(oldBits)
+		}
+		newBits = math.Float64bits(newValue)
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			if math.IsInf(newValue, 0) {
+				a.overflowed(math.Float64frombits(oldBits))
+			}
+			a.crossed(math.Float64frombits(oldBits), newValue)
+			return newValue
+		}
+		a.backoff(failures)
+	}
+}
+
+// AddAndSwap attempts to add delta to the value stored in the atomic float and
+// return both the value it replaced and the new value, from the same atomic
+// update.
+func (a *atomicFloatCAS) AddAndSwap(delta float64) (old, new float64) {
+	for failures := 1; ; failures++ {
+		oldBits := a.u64.Load()
+		old = math.Float64frombits(oldBits)
+		new = old + delta
+		if a.rejects(new) {
+			return old, old
+		}
+		if a.u64.CompareAndSwap(oldBits, math.Float64bits(new)) {
+			if math.IsInf(new, 0) {
+				a.overflowed(old)
+			}
+			a.crossed(old, new)
+			return old, new
+		}
+		a.backoff(failures)
+	}
+}
+
+// OnOverflow registers fn to be called whenever Add changes the value stored in
+// the atomic float from a finite value to ±Inf, replacing any function
+// previously registered, or removing it when fn is nil. fn is passed the last
+// finite value, and is called on the goroutine whose compare-and-swap
+// committed the overflow, so concurrent adders never invoke it twice for the
+// same overflow.
+func (a *atomicFloatCAS) OnOverflow(fn func(oldFinite float64)) {
+	if fn == nil {
+		a.onOverflow.Store(nil)
+		return
+	}
+	a.onOverflow.Store(&fn)
+}
+
+// overflowed invokes the registered overflow function when old is finite.
+func (a *atomicFloatCAS) overflowed(old float64) {
+	if math.IsInf(old, 0) || math.IsNaN(old) {
+		return
+	}
+	if fn := a.onOverflow.Load(); fn != nil {
+		(*fn)(old)
+	}
+}
+
+// AddChecked attempts to add delta to the value stored in the atomic float and
+// return the new value. When the new value would be NaN, or ±Inf, and the
+// stored value was not already, the stored value is left untouched, and
+// AddChecked returns it along with ErrNaNResult or ErrInfResult.
+func (a *atomicFloatCAS) AddChecked(delta float64) (float64, error) {
+	for failures := 1; ; failures++ {
+		oldBits := a.u64.Load()
+		oldValue := math.Float64frombits(oldBits)
+		newValue := oldValue + delta
+		if err := checkResult(oldValue, newValue); err != nil {
+			return oldValue, err
+		}
+		if a.u64.CompareAndSwap(oldBits, math.Float64bits(newValue)) {
+			a.crossed(oldValue, newValue)
+			return newValue, nil
+		}
+		a.backoff(failures)
+	}
+}
+
+// Sub attempts to subtract delta from the value stored in the atomic float and
+// return the new value.
+func (a *atomicFloatCAS) Sub(delta float64) float64 {
+	var newValue float64
+	var oldBits, newBits uint64
+	for failures := 1; ; failures++ {
+		oldBits = a.u64.Load()
+		newValue = math.Float64frombits(oldBits) - delta
+		if a.rejects(newValue) {
+			return math.Float64frombits(oldBits)
+		}
+		newBits = math.Float64bits(newValue)
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			a.crossed(math.Float64frombits(oldBits), newValue)
+			return newValue
+		}
+		a.backoff(failures)
+	}
+}
+
+// Mul attempts to multiply the value stored in the atomic float by factor and
+// return the new value.
+func (a *atomicFloatCAS) Mul(factor float64) float64 {
+	var newValue float64
+	var oldBits, newBits uint64
+	for failures := 1; ; failures++ {
+		oldBits = a.u64.Load()
+		newValue = math.Float64frombits(oldBits) * factor
+		if a.rejects(newValue) {
+			return math.Float64frombits(oldBits)
+		}
+		newBits = math.Float64bits(newValue)
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			a.crossed(math.Float64frombits(oldBits), newValue)
+			return newValue
+		}
+		a.backoff(failures)
+	}
+}
+
+// Div attempts to divide the value stored in the atomic float by divisor and
+// return the new value. Division by zero does not panic, but follows IEEE 754
+// semantics, producing ±Inf, or NaN when the stored value is also zero.
+func (a *atomicFloatCAS) Div(divisor float64) float64 {
+	var newValue float64
+	var oldBits, newBits uint64
+	for failures := 1; ; failures++ {
+		oldBits = a.u64.Load()
+		newValue = math.Float64frombits(oldBits) / divisor
+		if a.rejects(newValue) {
+			return math.Float64frombits(oldBits)
+		}
 		newBits = math.Float64bits(newValue)
-		if atomic.CompareAndSwapUint64(&a.u64, oldBits, newBits) {
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			a.crossed(math.Float64frombits(oldBits), newValue)
+			return newValue
+		}
+		a.backoff(failures)
+	}
+}
+
+// Max atomically stores v when it is greater than the value stored in the atomic
+// float, and returns the resulting value. As with math.Max, when either v or
+// the stored value is NaN the result is NaN, and +0 is considered greater than
+// -0.
+func (a *atomicFloatCAS) Max(v float64) float64 {
+	for failures := 1; ; failures++ {
+		oldBits := a.u64.Load()
+		newValue := math.Max(math.Float64frombits(oldBits), v)
+		if a.rejects(newValue) {
+			return math.Float64frombits(oldBits)
+		}
+		newBits := math.Float64bits(newValue)
+		if newBits == oldBits || a.u64.CompareAndSwap(oldBits, newBits) {
+			a.crossed(math.Float64frombits(oldBits), newValue)
+			return newValue
+		}
+		a.backoff(failures)
+	}
+}
+
+// Min atomically stores v when it is less than the value stored in the atomic
+// float, and returns the resulting value. As with math.Min, when either v or
+// the stored value is NaN the result is NaN, and -0 is considered less than
+// +0.
+func (a *atomicFloatCAS) Min(v float64) float64 {
+	for failures := 1; ; failures++ {
+		oldBits := a.u64.Load()
+		newValue := math.Min(math.Float64frombits(oldBits), v)
+		if a.rejects(newValue) {
+			return math.Float64frombits(oldBits)
+		}
+		newBits := math.Float64bits(newValue)
+		if newBits == oldBits || a.u64.CompareAndSwap(oldBits, newBits) {
+			a.crossed(math.Float64frombits(oldBits), newValue)
 			return newValue
 		}
+		a.backoff(failures)
+	}
+}
+
+// MaxIgnoreNaN atomically stores v when it is greater than the value stored in
+// the atomic float, and returns the resulting value. Unlike Max, which follows
+// math.Max, a NaN v is ignored, leaving the stored value unchanged, and a
+// stored NaN is treated as unset, so it is replaced by the next v that is not
+// NaN.
+func (a *atomicFloatCAS) MaxIgnoreNaN(v float64) float64 {
+	if math.IsNaN(v) {
+		return a.Load()
+	}
+	for failures := 1; ; failures++ {
+		oldBits := a.u64.Load()
+		newValue := maxIgnoreNaN(math.Float64frombits(oldBits), v)
+		if a.rejects(newValue) {
+			return math.Float64frombits(oldBits)
+		}
+		newBits := math.Float64bits(newValue)
+		if newBits == oldBits || a.u64.CompareAndSwap(oldBits, newBits) {
+			a.crossed(math.Float64frombits(oldBits), newValue)
+			return newValue
+		}
+		a.backoff(failures)
+	}
+}
+
+// MinIgnoreNaN atomically stores v when it is less than the value stored in
+// the atomic float, and returns the resulting value. Unlike Min, which follows
+// math.Min, a NaN v is ignored, leaving the stored value unchanged, and a
+// stored NaN is treated as unset, so it is replaced by the next v that is not
+// NaN.
+func (a *atomicFloatCAS) MinIgnoreNaN(v float64) float64 {
+	if math.IsNaN(v) {
+		return a.Load()
+	}
+	for failures := 1; ; failures++ {
+		oldBits := a.u64.Load()
+		newValue := minIgnoreNaN(math.Float64frombits(oldBits), v)
+		if a.rejects(newValue) {
+			return math.Float64frombits(oldBits)
+		}
+		newBits := math.Float64bits(newValue)
+		if newBits == oldBits || a.u64.CompareAndSwap(oldBits, newBits) {
+			a.crossed(math.Float64frombits(oldBits), newValue)
+			return newValue
+		}
+		a.backoff(failures)
+	}
+}
+
+// SwapIfGreater atomically stores v when it is greater than the value stored in
+// the atomic float, and returns the previous value along with whether this
+// call stored v. Unlike Max, it reports whether the caller's value was the one
+// installed. As NaN compares false with everything, a NaN v is never stored,
+// and neither is any v while the stored value is NaN.
+func (a *atomicFloatCAS) SwapIfGreater(v float64) (prev float64, swapped bool) {
+	return a.swapIf(v, func(prev, v float64) bool { return v > prev })
+}
+
+// SwapIfLess atomically stores v when it is less than the value stored in the
+// atomic float, and returns the previous value along with whether this call
+// stored v. Unlike Min, it reports whether the caller's value was the one
+// installed. As NaN compares false with everything, a NaN v is never stored,
+// and neither is any v while the stored value is NaN.
+func (a *atomicFloatCAS) SwapIfLess(v float64) (prev float64, swapped bool) {
+	return a.swapIf(v, func(prev, v float64) bool { return v < prev })
+}
+
+// swapIf atomically stores v when beats returns true for the stored value and
+// v, and returns the previous value along with whether this call stored v.
+func (a *atomicFloatCAS) swapIf(v float64, beats func(prev, v float64) bool) (float64, bool) {
+	if a.rejects(v) {
+		return a.Load(), false
+	}
+	newBits := math.Float64bits(v)
+	for failures := 1; ; failures++ {
+		oldBits := a.u64.Load()
+		prev := math.Float64frombits(oldBits)
+		if !beats(prev, v) {
+			return prev, false
+		}
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			a.crossed(prev, v)
+			return prev, true
+		}
+		a.backoff(failures)
+	}
+}
+
+// maxIgnoreNaN returns the greater of old and v, which must not be NaN, or v
+// when old is NaN.
+func maxIgnoreNaN(old, v float64) float64 {
+	if math.IsNaN(old) {
+		return v
+	}
+	return math.Max(old, v)
+}
+
+// minIgnoreNaN returns the lesser of old and v, which must not be NaN, or v
+// when old is NaN.
+func minIgnoreNaN(old, v float64) float64 {
+	if math.IsNaN(old) {
+		return v
+	}
+	return math.Min(old, v)
+}
+
+// toInt64 returns v, which must be integral, converted to an int64, clamped to
+// the range of int64, or 0 when v is NaN.
+func toInt64(v float64) int64 {
+	switch {
+	case math.IsNaN(v):
+		return 0
+	case v >= math.MaxInt64: // rounds to 2^63, which is out of range
+		return math.MaxInt64
+	case v <= math.MinInt64:
+		return math.MinInt64
+	}
+	return int64(v)
+}
+
+// Update atomically replaces the value stored in the atomic float with the
+// result of calling fn with the current value, and returns the new value. fn
+// is invoked inside the retry loop, and may be called more than once
+// under contention, so it must be free of side effects.
+func (a *atomicFloatCAS) Update(fn func(old float64) (new float64)) float64 {
+	var newValue float64
+	var oldBits, newBits uint64
+	for failures := 1; ; failures++ {
+		oldBits = a.u64.Load()
+		newValue = fn(math.Float64frombits(oldBits))
+		if a.rejects(newValue) {
+			return math.Float64frombits(oldBits)
+		}
+		newBits = math.Float64bits(newValue)
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			a.crossed(math.Float64frombits(oldBits), newValue)
+			return newValue
+		}
+		a.backoff(failures)
+	}
+}
+
+// TryAdd attempts to add delta to the value stored in the atomic float, giving
+// up after maxAttempts failed compare-and-swap operations. It returns the new
+// value and true when the add took place, or zero and false when it did not, in
+// which case the stored value is left untouched. When maxAttempts is less than
+// or equal to zero, TryAdd gives up after the number of failures configured
+// WithMaxRetries, or when none was, retries until it succeeds, just like Add.
+func (a *atomicFloatCAS) TryAdd(delta float64, maxAttempts int) (float64, bool) {
+	if maxAttempts <= 0 && a.opts != nil {
+		maxAttempts = a.opts.maxRetries
+	}
+	var newValue float64
+	var oldBits, newBits uint64
+	for attempt := 1; ; attempt++ {
+		oldBits = a.u64.Load()
+		newValue = math.Float64frombits(oldBits) + delta
+		if a.rejects(newValue) {
+			return 0, false
+		}
+		newBits = math.Float64bits(newValue)
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			a.crossed(math.Float64frombits(oldBits), newValue)
+			return newValue, true
+		}
+		if attempt == maxAttempts {
+			return 0, false
+		}
+		a.backoff(attempt)
+	}
+}
+
+// Inc atomically increments the value stored in the atomic float by one and
+// returns the new value.
+func (a *atomicFloatCAS) Inc() float64 {
+	var newValue float64
+	var oldBits, newBits uint64
+	for failures := 1; ; failures++ {
+		oldBits = a.u64.Load()
+		newValue = math.Float64frombits(oldBits) + 1
+		if a.rejects(newValue) {
+			return math.Float64frombits(oldBits)
+		}
+		newBits = math.Float64bits(newValue)
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			a.crossed(math.Float64frombits(oldBits), newValue)
+			return newValue
+		}
+		a.backoff(failures)
+	}
+}
+
+// Dec atomically decrements the value stored in the atomic float by one and
+// returns the new value.
+func (a *atomicFloatCAS) Dec() float64 {
+	var newValue float64
+	var oldBits, newBits uint64
+	for failures := 1; ; failures++ {
+		oldBits = a.u64.Load()
+		newValue = math.Float64frombits(oldBits) - 1
+		if a.rejects(newValue) {
+			return math.Float64frombits(oldBits)
+		}
+		newBits = math.Float64bits(newValue)
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			a.crossed(math.Float64frombits(oldBits), newValue)
+			return newValue
+		}
+		a.backoff(failures)
 	}
 }
 
 // Load atomically loads the current atomic float value.
 func (a *atomicFloatCAS) Load() float64 {
-	return math.Float64frombits(atomic.LoadUint64(&a.u64))
+	return math.Float64frombits(a.u64.Load())
 }
 
 // Store atomically stores new into the atomic float.
 func (a *atomicFloatCAS) Store(new float64) {
-	atomic.StoreUint64(&a.u64, math.Float64bits(new))
+	if a.rejects(new) {
+		return
+	}
+	a.storeBits(math.Float64bits(new))
 }
 
 // Swap atomically stores new and returns the previous value.
 func (a *atomicFloatCAS) Swap(new float64) float64 {
-	return math.Float64frombits(atomic.SwapUint64(&a.u64, math.Float64bits(new)))
+	if a.rejects(new) {
+		return a.Load()
+	}
+	return math.Float64frombits(a.swapBits(math.Float64bits(new)))
+}
+
+// Bits atomically loads the IEEE 754 binary representation of the current
+// atomic float value.
+func (a *atomicFloatCAS) Bits() uint64 {
+	return a.u64.Load()
+}
+
+// Int64 atomically loads the current atomic float value and returns it
+// truncated toward zero, clamped to the range of int64, or 0 when it is NaN.
+func (a *atomicFloatCAS) Int64() int64 {
+	return toInt64(math.Trunc(a.Load()))
+}
+
+// Round atomically loads the current atomic float value and returns it rounded
+// to the nearest integer, with halves rounded to even, clamped to the range of
+// int64, or 0 when it is NaN.
+func (a *atomicFloatCAS) Round() int64 {
+	return toInt64(math.RoundToEven(a.Load()))
+}
+
+// Reset atomically stores 0 into the atomic float.
+func (a *atomicFloatCAS) Reset() {
+	a.storeBits(0)
+}
+
+// LoadAndReset atomically stores 0 into the atomic float and returns the
+// previous value.
+func (a *atomicFloatCAS) LoadAndReset() float64 {
+	return math.Float64frombits(a.swapBits(0))
+}
+
+// DrainIfAtLeast atomically stores 0 into the atomic float and returns the
+// previous value and true, when the previous value is at least threshold.
+// Otherwise it leaves the value untouched, and returns it and false. NaN is
+// never at least any threshold.
+func (a *atomicFloatCAS) DrainIfAtLeast(threshold float64) (float64, bool) {
+	for failures := 1; ; failures++ {
+		oldBits := a.u64.Load()
+		oldValue := math.Float64frombits(oldBits)
+		if !(oldValue >= threshold) {
+			return oldValue, false
+		}
+		if a.u64.CompareAndSwap(oldBits, 0) {
+			a.crossed(oldValue, 0)
+			return oldValue, true
+		}
+		a.backoff(failures)
+	}
+}
+
+// Clone returns a new, independent atomic float initialized to the current
+// value, preserving its bit pattern exactly.
+func (a *atomicFloatCAS) Clone() *atomicFloatCAS {
+	b := NewAtomicFloatCASFromBits(a.u64.Load())
+	b.opts = a.opts
+	b.format.bits.Store(a.format.bits.Load())
+	return b
+}
+
+// CompareAndSwap atomically stores new when the current value is old, and
+// returns true when the swap took place. Values are compared by their bit
+// patterns, so +0 and -0 are distinct. Because NaN never compares equal to
+// anything, CompareAndSwap with old equal to NaN never succeeds.
+func (a *atomicFloatCAS) CompareAndSwap(old, new float64) bool {
+	if old != old {
+		return false // NaN
+	}
+	if a.rejects(new) {
+		return false
+	}
+	if !a.u64.CompareAndSwap(math.Float64bits(old), math.Float64bits(new)) {
+		return false
+	}
+	a.crossed(old, new)
+	return true
+}
+
+// CompareAndSwapEpsilon atomically stores new when the current value is within
+// epsilon of old, and returns true when the swap took place. A negative epsilon
+// is treated as its absolute value, and an epsilon of zero performs the same
+// exact comparison as CompareAndSwap.
+func (a *atomicFloatCAS) CompareAndSwapEpsilon(old, new, epsilon float64) bool {
+	if epsilon == 0 {
+		return a.CompareAndSwap(old, new)
+	}
+	if a.rejects(new) {
+		return false
+	}
+	epsilon = math.Abs(epsilon)
+	newBits := math.Float64bits(new)
+	for failures := 1; ; failures++ {
+		curBits := a.u64.Load()
+		if !(math.Abs(math.Float64frombits(curBits)-old) <= epsilon) {
+			return false
+		}
+		if a.u64.CompareAndSwap(curBits, newBits) {
+			a.crossed(math.Float64frombits(curBits), new)
+			return true
+		}
+		a.backoff(failures)
+	}
+}
+
+// SetFormat changes the strconv.FormatFloat verb and precision with which
+// String and MarshalText format the atomic float value, which by default are
+// 'g' and -1, the shortest representation that parses back to the same value.
+// It may be called concurrently with formatting, and panics when verb is not
+// one of 'b', 'e', 'E', 'f', 'g', 'G', 'x', or 'X', or prec is less than -1.
+func (a *atomicFloatCAS) SetFormat(verb byte, prec int) {
+	a.format.set(verb, prec)
+}
+
+// String returns the current atomic float value formatted as configured by
+// SetFormat, by default in the shortest representation that parses back to
+// the same value.
+func (a *atomicFloatCAS) String() string {
+	return string(a.format.appendFloat(nil, a.Load()))
+}
+
+// Format implements fmt.Formatter, formatting the current atomic float value
+// as fmt would format a float64, including its width, precision, and flags.
+// Verbs that are not meaningful for floating point values print the value as
+// if by the 'v' verb.
+func (a *atomicFloatCAS) Format(s fmt.State, verb rune) {
+	formatFloat(s, verb, a.Load())
+}
+
+// MarshalJSON implements json.Marshaler, encoding the current atomic float value
+// as a JSON number. Because JSON cannot represent NaN or ±Inf, those values are
+// encoded as null.
+func (a *atomicFloatCAS) MarshalJSON() ([]byte, error) {
+	return appendJSONFloat(nil, a.Load()), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, atomically storing the JSON number
+// in b into the atomic float. As is conventional, null leaves the value
+// unchanged. Numbers that overflow a float64 are rejected with an error.
+func (a *atomicFloatCAS) UnmarshalJSON(b []byte) error {
+	if a == nil {
+		return errNilUnmarshal("UnmarshalJSON")
+	}
+	f, ok, err := parseJSONFloat(b)
+	if ok {
+		a.Store(f)
+	}
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the current atomic
+// float value as configured by SetFormat, by default in the shortest
+// representation that parses back to the same value. Unlike JSON, NaN and ±Inf
+// are preserved.
+func (a *atomicFloatCAS) MarshalText() ([]byte, error) {
+	return a.format.appendFloat(nil, a.Load()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing b as a floating
+// point number, including NaN and ±Inf, and atomically storing it into the
+// atomic float.
+func (a *atomicFloatCAS) UnmarshalText(b []byte) error {
+	if a == nil {
+		return errNilUnmarshal("UnmarshalText")
+	}
+	f, err := parseTextFloat(b)
+	if err != nil {
+		return err
+	}
+	if a.rejects(f) {
+		return fmt.Errorf("%w: %v", ErrNotFinite, f)
+	}
+	a.Store(f)
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, encoding the IEEE 754
+// binary representation of the current atomic float value as 8 big-endian
+// bytes. This preserves every bit pattern exactly, including NaN payloads and
+// the sign of zero.
+func (a *atomicFloatCAS) MarshalBinary() ([]byte, error) {
+	return binary.BigEndian.AppendUint64(nil, a.Bits()), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, atomically storing
+// the value whose IEEE 754 binary representation is encoded as the 8
+// big-endian bytes in b.
+func (a *atomicFloatCAS) UnmarshalBinary(b []byte) error {
+	if a == nil {
+		return errNilUnmarshal("UnmarshalBinary")
+	}
+	if len(b) != 8 {
+		return errBinaryLength(len(b))
+	}
+	bits := binary.BigEndian.Uint64(b)
+	if f := math.Float64frombits(bits); a.rejects(f) {
+		return fmt.Errorf("%w: %v", ErrNotFinite, f)
+	}
+	a.storeBits(bits)
+	return nil
+}
+
+// Scan implements sql.Scanner, atomically storing the database value src into
+// the atomic float. src may be a float64, int64, or a []byte or string holding
+// a number. A NULL src leaves the value unchanged.
+func (a *atomicFloatCAS) Scan(src any) error {
+	f, ok, err := parseSQLFloat(src)
+	if ok && a.rejects(f) {
+		return fmt.Errorf("%w: %v", ErrNotFinite, f)
+	}
+	if ok {
+		a.Store(f)
+	}
+	return err
+}
+
+// Value implements driver.Valuer, returning the current atomic float value as
+// a float64.
+func (a *atomicFloatCAS) Value() (driver.Value, error) {
+	return a.Load(), nil
+}
+
+// LogValue implements slog.LogValuer, so that structured logs record the
+// current atomic float value as a number.
+func (a *atomicFloatCAS) LogValue() slog.Value {
+	return slog.Float64Value(a.Load())
 }
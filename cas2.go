@@ -1,24 +1,234 @@
 package atomic
 
 import (
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
 	"math"
 	"sync/atomic"
 )
 
-type atomicFloatCAS2 struct{ u64 uint64 }
+type atomicFloatCAS2 struct {
+	u64    atomic.Uint64
+	format textFormat
+}
 
 func NewAtomicFloatCAS2(initial float64) *atomicFloatCAS2 {
-	return &atomicFloatCAS2{u64: math.Float64bits(initial)}
+	a := new(atomicFloatCAS2)
+	a.u64.Store(math.Float64bits(initial))
+	return a
 }
 
 // Add attempts to add delta to the value stored in the atomic float and return
 // the new value.
 func (a *atomicFloatCAS2) Add(delta float64) float64 {
 loop:
-	oldBits := atomic.LoadUint64(&a.u64)
+	oldBits := a.u64.Load()
+	newValue := math.Float64frombits(oldBits) + delta
+	newBits := math.Float64bits(newValue)
+	if !a.u64.CompareAndSwap(oldBits, newBits) {
+		goto loop
+	}
+	return newValue
+}
+
+// AddAndSwap attempts to add delta to the value stored in the atomic float and
+// return both the value it replaced and the new value, from the same atomic
+// update.
+func (a *atomicFloatCAS2) AddAndSwap(delta float64) (old, new float64) {
+loop:
+	oldBits := a.u64.Load()
+	old = math.Float64frombits(oldBits)
+	new = old + delta
+	if !a.u64.CompareAndSwap(oldBits, math.Float64bits(new)) {
+		goto loop
+	}
+	return old, new
+}
+
+// AddChecked attempts to add delta to the value stored in the atomic float and
+// return the new value. When the new value would be NaN, or ±Inf, and the
+// stored value was not already, the stored value is left untouched, and
+// AddChecked returns it along with ErrNaNResult or ErrInfResult.
+func (a *atomicFloatCAS2) AddChecked(delta float64) (float64, error) {
+loop:
+	oldBits := a.u64.Load()
+	oldValue := math.Float64frombits(oldBits)
+	newValue := oldValue + delta
+	if err := checkResult(oldValue, newValue); err != nil {
+		return oldValue, err
+	}
+	if !a.u64.CompareAndSwap(oldBits, math.Float64bits(newValue)) {
+		goto loop
+	}
+	return newValue, nil
+}
+
+// Sub attempts to subtract delta from the value stored in the atomic float and
+// return the new value.
+func (a *atomicFloatCAS2) Sub(delta float64) float64 {
+loop:
+	oldBits := a.u64.Load()
+	newValue := math.Float64frombits(oldBits) - delta
+	newBits := math.Float64bits(newValue)
+	if !a.u64.CompareAndSwap(oldBits, newBits) {
+		goto loop
+	}
+	return newValue
+}
+
+// Mul attempts to multiply the value stored in the atomic float by factor and
+// return the new value.
+func (a *atomicFloatCAS2) Mul(factor float64) float64 {
+loop:
+	oldBits := a.u64.Load()
+	newValue := math.Float64frombits(oldBits) * factor
+	newBits := math.Float64bits(newValue)
+	if !a.u64.CompareAndSwap(oldBits, newBits) {
+		goto loop
+	}
+	return newValue
+}
+
+// Div attempts to divide the value stored in the atomic float by divisor and
+// return the new value. Division by zero does not panic, but follows IEEE 754
+// semantics, producing ±Inf, or NaN when the stored value is also zero.
+func (a *atomicFloatCAS2) Div(divisor float64) float64 {
+loop:
+	oldBits := a.u64.Load()
+	newValue := math.Float64frombits(oldBits) / divisor
+	newBits := math.Float64bits(newValue)
+	if !a.u64.CompareAndSwap(oldBits, newBits) {
+		goto loop
+	}
+	return newValue
+}
+
+// Max atomically stores v when it is greater than the value stored in the atomic
+// float, and returns the resulting value. As with math.Max, when either v or
+// the stored value is NaN the result is NaN, and +0 is considered greater than
+// -0.
+func (a *atomicFloatCAS2) Max(v float64) float64 {
+loop:
+	oldBits := a.u64.Load()
+	newValue := math.Max(math.Float64frombits(oldBits), v)
+	newBits := math.Float64bits(newValue)
+	if newBits != oldBits && !a.u64.CompareAndSwap(oldBits, newBits) {
+		goto loop
+	}
+	return newValue
+}
+
+// Min atomically stores v when it is less than the value stored in the atomic
+// float, and returns the resulting value. As with math.Min, when either v or
+// the stored value is NaN the result is NaN, and -0 is considered less than
+// +0.
+func (a *atomicFloatCAS2) Min(v float64) float64 {
+loop:
+	oldBits := a.u64.Load()
+	newValue := math.Min(math.Float64frombits(oldBits), v)
+	newBits := math.Float64bits(newValue)
+	if newBits != oldBits && !a.u64.CompareAndSwap(oldBits, newBits) {
+		goto loop
+	}
+	return newValue
+}
+
+// MaxIgnoreNaN atomically stores v when it is greater than the value stored in
+// the atomic float, and returns the resulting value. Unlike Max, which follows
+// math.Max, a NaN v is ignored, leaving the stored value unchanged, and a
+// stored NaN is treated as unset, so it is replaced by the next v that is not
+// NaN.
+func (a *atomicFloatCAS2) MaxIgnoreNaN(v float64) float64 {
+	if math.IsNaN(v) {
+		return a.Load()
+	}
+loop:
+	oldBits := a.u64.Load()
+	newValue := maxIgnoreNaN(math.Float64frombits(oldBits), v)
+	newBits := math.Float64bits(newValue)
+	if newBits != oldBits && !a.u64.CompareAndSwap(oldBits, newBits) {
+		goto loop
+	}
+	return newValue
+}
+
+// MinIgnoreNaN atomically stores v when it is less than the value stored in
+// the atomic float, and returns the resulting value. Unlike Min, which follows
+// math.Min, a NaN v is ignored, leaving the stored value unchanged, and a
+// stored NaN is treated as unset, so it is replaced by the next v that is not
+// NaN.
+func (a *atomicFloatCAS2) MinIgnoreNaN(v float64) float64 {
+	if math.IsNaN(v) {
+		return a.Load()
+	}
+loop:
+	oldBits := a.u64.Load()
+	newValue := minIgnoreNaN(math.Float64frombits(oldBits), v)
+	newBits := math.Float64bits(newValue)
+	if newBits != oldBits && !a.u64.CompareAndSwap(oldBits, newBits) {
+		goto loop
+	}
+	return newValue
+}
+
+// Update atomically replaces the value stored in the atomic float with the
+// result of calling fn with the current value, and returns the new value. fn
+// is invoked inside the retry loop, and may be called more than once
+// under contention, so it must be free of side effects.
+func (a *atomicFloatCAS2) Update(fn func(old float64) (new float64)) float64 {
+loop:
+	oldBits := a.u64.Load()
+	newValue := fn(math.Float64frombits(oldBits))
+	newBits := math.Float64bits(newValue)
+	if !a.u64.CompareAndSwap(oldBits, newBits) {
+		goto loop
+	}
+	return newValue
+}
+
+// TryAdd attempts to add delta to the value stored in the atomic float, giving
+// up after maxAttempts failed compare-and-swap operations. It returns the new
+// value and true when the add took place, or zero and false when it did not, in
+// which case the stored value is left untouched. When maxAttempts is less than
+// or equal to zero, TryAdd retries until it succeeds, just like Add.
+func (a *atomicFloatCAS2) TryAdd(delta float64, maxAttempts int) (float64, bool) {
+	var attempt int
+loop:
+	oldBits := a.u64.Load()
 	newValue := math.Float64frombits(oldBits) + delta
 	newBits := math.Float64bits(newValue)
-	if !atomic.CompareAndSwapUint64(&a.u64, oldBits, newBits) {
+	if !a.u64.CompareAndSwap(oldBits, newBits) {
+		if attempt++; attempt == maxAttempts {
+			return 0, false
+		}
+		goto loop
+	}
+	return newValue, true
+}
+
+// Inc atomically increments the value stored in the atomic float by one and
+// returns the new value.
+func (a *atomicFloatCAS2) Inc() float64 {
+loop:
+	oldBits := a.u64.Load()
+	newValue := math.Float64frombits(oldBits) + 1
+	newBits := math.Float64bits(newValue)
+	if !a.u64.CompareAndSwap(oldBits, newBits) {
+		goto loop
+	}
+	return newValue
+}
+
+// Dec atomically decrements the value stored in the atomic float by one and
+// returns the new value.
+func (a *atomicFloatCAS2) Dec() float64 {
+loop:
+	oldBits := a.u64.Load()
+	newValue := math.Float64frombits(oldBits) - 1
+	newBits := math.Float64bits(newValue)
+	if !a.u64.CompareAndSwap(oldBits, newBits) {
 		goto loop
 	}
 	return newValue
@@ -26,15 +236,216 @@ loop:
 
 // Load atomically loads the current atomic float value.
 func (a *atomicFloatCAS2) Load() float64 {
-	return math.Float64frombits(atomic.LoadUint64(&a.u64))
+	return math.Float64frombits(a.u64.Load())
 }
 
 // Store atomically stores new into the atomic float.
 func (a *atomicFloatCAS2) Store(new float64) {
-	atomic.StoreUint64(&a.u64, math.Float64bits(new))
+	a.u64.Store(math.Float64bits(new))
 }
 
 // Swap atomically stores new and returns the previous value.
 func (a *atomicFloatCAS2) Swap(new float64) float64 {
-	return math.Float64frombits(atomic.SwapUint64(&a.u64, math.Float64bits(new)))
+	return math.Float64frombits(a.u64.Swap(math.Float64bits(new)))
+}
+
+// Bits atomically loads the IEEE 754 binary representation of the current
+// atomic float value.
+func (a *atomicFloatCAS2) Bits() uint64 {
+	return a.u64.Load()
+}
+
+// Int64 atomically loads the current atomic float value and returns it
+// truncated toward zero, clamped to the range of int64, or 0 when it is NaN.
+func (a *atomicFloatCAS2) Int64() int64 {
+	return toInt64(math.Trunc(a.Load()))
+}
+
+// Round atomically loads the current atomic float value and returns it rounded
+// to the nearest integer, with halves rounded to even, clamped to the range of
+// int64, or 0 when it is NaN.
+func (a *atomicFloatCAS2) Round() int64 {
+	return toInt64(math.RoundToEven(a.Load()))
+}
+
+// Reset atomically stores 0 into the atomic float.
+func (a *atomicFloatCAS2) Reset() {
+	a.u64.Store(0)
+}
+
+// LoadAndReset atomically stores 0 into the atomic float and returns the
+// previous value.
+func (a *atomicFloatCAS2) LoadAndReset() float64 {
+	return math.Float64frombits(a.u64.Swap(0))
+}
+
+// DrainIfAtLeast atomically stores 0 into the atomic float and returns the
+// previous value and true, when the previous value is at least threshold.
+// Otherwise it leaves the value untouched, and returns it and false. NaN is
+// never at least any threshold.
+func (a *atomicFloatCAS2) DrainIfAtLeast(threshold float64) (float64, bool) {
+loop:
+	oldBits := a.u64.Load()
+	oldValue := math.Float64frombits(oldBits)
+	if !(oldValue >= threshold) {
+		return oldValue, false
+	}
+	if !a.u64.CompareAndSwap(oldBits, 0) {
+		goto loop
+	}
+	return oldValue, true
+}
+
+// Clone returns a new, independent atomic float initialized to the current
+// value, preserving its bit pattern exactly.
+func (a *atomicFloatCAS2) Clone() *atomicFloatCAS2 {
+	b := new(atomicFloatCAS2)
+	b.u64.Store(a.u64.Load())
+	b.format.bits.Store(a.format.bits.Load())
+	return b
+}
+
+// CompareAndSwap atomically stores new when the current value is old, and
+// returns true when the swap took place. Values are compared by their bit
+// patterns, so +0 and -0 are distinct. Because NaN never compares equal to
+// anything, CompareAndSwap with old equal to NaN never succeeds.
+func (a *atomicFloatCAS2) CompareAndSwap(old, new float64) bool {
+	if old != old {
+		return false // NaN
+	}
+	return a.u64.CompareAndSwap(math.Float64bits(old), math.Float64bits(new))
+}
+
+// CompareAndSwapEpsilon atomically stores new when the current value is within
+// epsilon of old, and returns true when the swap took place. A negative epsilon
+// is treated as its absolute value, and an epsilon of zero performs the same
+// exact comparison as CompareAndSwap.
+func (a *atomicFloatCAS2) CompareAndSwapEpsilon(old, new, epsilon float64) bool {
+	if epsilon == 0 {
+		return a.CompareAndSwap(old, new)
+	}
+	epsilon = math.Abs(epsilon)
+	newBits := math.Float64bits(new)
+loop:
+	curBits := a.u64.Load()
+	if !(math.Abs(math.Float64frombits(curBits)-old) <= epsilon) {
+		return false
+	}
+	if !a.u64.CompareAndSwap(curBits, newBits) {
+		goto loop
+	}
+	return true
+}
+
+// SetFormat changes the strconv.FormatFloat verb and precision with which
+// String and MarshalText format the atomic float value, which by default are
+// 'g' and -1, the shortest representation that parses back to the same value.
+// It may be called concurrently with formatting, and panics when verb is not
+// one of 'b', 'e', 'E', 'f', 'g', 'G', 'x', or 'X', or prec is less than -1.
+func (a *atomicFloatCAS2) SetFormat(verb byte, prec int) {
+	a.format.set(verb, prec)
+}
+
+// String returns the current atomic float value formatted as configured by
+// SetFormat, by default in the shortest representation that parses back to
+// the same value.
+func (a *atomicFloatCAS2) String() string {
+	return string(a.format.appendFloat(nil, a.Load()))
+}
+
+// Format implements fmt.Formatter, formatting the current atomic float value
+// as fmt would format a float64, including its width, precision, and flags.
+// Verbs that are not meaningful for floating point values print the value as
+// if by the 'v' verb.
+func (a *atomicFloatCAS2) Format(s fmt.State, verb rune) {
+	formatFloat(s, verb, a.Load())
+}
+
+// MarshalJSON implements json.Marshaler, encoding the current atomic float value
+// as a JSON number. Because JSON cannot represent NaN or ±Inf, those values are
+// encoded as null.
+func (a *atomicFloatCAS2) MarshalJSON() ([]byte, error) {
+	return appendJSONFloat(nil, a.Load()), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, atomically storing the JSON number
+// in b into the atomic float. As is conventional, null leaves the value
+// unchanged. Numbers that overflow a float64 are rejected with an error.
+func (a *atomicFloatCAS2) UnmarshalJSON(b []byte) error {
+	if a == nil {
+		return errNilUnmarshal("UnmarshalJSON")
+	}
+	f, ok, err := parseJSONFloat(b)
+	if ok {
+		a.Store(f)
+	}
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the current atomic
+// float value as configured by SetFormat, by default in the shortest
+// representation that parses back to the same value. Unlike JSON, NaN and ±Inf
+// are preserved.
+func (a *atomicFloatCAS2) MarshalText() ([]byte, error) {
+	return a.format.appendFloat(nil, a.Load()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing b as a floating
+// point number, including NaN and ±Inf, and atomically storing it into the
+// atomic float.
+func (a *atomicFloatCAS2) UnmarshalText(b []byte) error {
+	if a == nil {
+		return errNilUnmarshal("UnmarshalText")
+	}
+	f, err := parseTextFloat(b)
+	if err != nil {
+		return err
+	}
+	a.Store(f)
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, encoding the IEEE 754
+// binary representation of the current atomic float value as 8 big-endian
+// bytes. This preserves every bit pattern exactly, including NaN payloads and
+// the sign of zero.
+func (a *atomicFloatCAS2) MarshalBinary() ([]byte, error) {
+	return binary.BigEndian.AppendUint64(nil, a.Bits()), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, atomically storing
+// the value whose IEEE 754 binary representation is encoded as the 8
+// big-endian bytes in b.
+func (a *atomicFloatCAS2) UnmarshalBinary(b []byte) error {
+	if a == nil {
+		return errNilUnmarshal("UnmarshalBinary")
+	}
+	if len(b) != 8 {
+		return errBinaryLength(len(b))
+	}
+	a.u64.Store(binary.BigEndian.Uint64(b))
+	return nil
+}
+
+// Scan implements sql.Scanner, atomically storing the database value src into
+// the atomic float. src may be a float64, int64, or a []byte or string holding
+// a number. A NULL src leaves the value unchanged.
+func (a *atomicFloatCAS2) Scan(src any) error {
+	f, ok, err := parseSQLFloat(src)
+	if ok {
+		a.Store(f)
+	}
+	return err
+}
+
+// Value implements driver.Valuer, returning the current atomic float value as
+// a float64.
+func (a *atomicFloatCAS2) Value() (driver.Value, error) {
+	return a.Load(), nil
+}
+
+// LogValue implements slog.LogValuer, so that structured logs record the
+// current atomic float value as a number.
+func (a *atomicFloatCAS2) LogValue() slog.Value {
+	return slog.Float64Value(a.Load())
 }
@@ -0,0 +1,42 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+type atomicFloat32CAS struct{ u32 uint32 }
+
+func NewAtomicFloat32CAS(initial float32) *atomicFloat32CAS {
+	return &atomicFloat32CAS{u32: math.Float32bits(initial)}
+}
+
+// Add attempts to add delta to the value stored in the atomic float and return
+// the new value.
+func (a *atomicFloat32CAS) Add(delta float32) float32 {
+	var newValue float32
+	var oldBits, newBits uint32
+	for {
+		oldBits = atomic.LoadUint32(&a.u32)
+		newValue = math.Float32frombits(oldBits) + delta
+		newBits = math.Float32bits(newValue)
+		if atomic.CompareAndSwapUint32(&a.u32, oldBits, newBits) {
+			return newValue
+		}
+	}
+}
+
+// Load atomically loads the current atomic float value.
+func (a *atomicFloat32CAS) Load() float32 {
+	return math.Float32frombits(atomic.LoadUint32(&a.u32))
+}
+
+// Store atomically stores new into the atomic float.
+func (a *atomicFloat32CAS) Store(new float32) {
+	atomic.StoreUint32(&a.u32, math.Float32bits(new))
+}
+
+// Swap atomically stores new and returns the previous value.
+func (a *atomicFloat32CAS) Swap(new float32) float32 {
+	return math.Float32frombits(atomic.SwapUint32(&a.u32, math.Float32bits(new)))
+}
@@ -0,0 +1,35 @@
+package atomic
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	// ErrNaNResult is returned by AddChecked when the add would change a value
+	// that is not NaN into NaN.
+	ErrNaNResult = errors.New("atomic: result is NaN")
+
+	// ErrInfResult is returned by AddChecked when the add would change a
+	// finite value into ±Inf.
+	ErrInfResult = errors.New("atomic: result is infinite")
+
+	// ErrNotFinite is returned by NewStrictAtomicFloat when the initial value
+	// is NaN or ±Inf, and wrapped by the UnmarshalText, UnmarshalBinary, and
+	// Scan methods of an atomic float constructed WithStrictFinite when the
+	// decoded value is.
+	ErrNotFinite = errors.New("atomic: value must be finite")
+)
+
+// checkResult returns the error describing why replacing old with new would
+// introduce a non-finite value, or nil when new is finite, or is no worse than
+// old.
+func checkResult(old, new float64) error {
+	switch {
+	case math.IsNaN(new) && !math.IsNaN(old):
+		return ErrNaNResult
+	case math.IsInf(new, 0) && !math.IsInf(old, 0) && !math.IsNaN(old):
+		return ErrInfResult
+	}
+	return nil
+}
@@ -0,0 +1,404 @@
+package atomic
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for deterministic tests of
+// time-dependent types.
+type fakeClock struct {
+	l   sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2019, 12, 5, 0, 0, 0, 0, time.UTC)}
+}
+
+// Now returns the current time of the clock.
+func (c *fakeClock) Now() time.Time {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.l.Lock()
+	c.now = c.now.Add(d)
+	c.l.Unlock()
+}
+
+func TestRateCounter(t *testing.T) {
+	clock := newFakeClock()
+	r := NewRateCounter(clock.Now)
+
+	parallel(10, func(int) {
+		for i := 0; i < 100; i++ {
+			r.Inc()
+			r.Add(2)
+		}
+	})
+	clock.Advance(2 * time.Second)
+	if got, want := r.Rate(), 1500.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	r.Add(50)
+	clock.Advance(500 * time.Millisecond)
+	if got, want := r.Rate(), 100.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	r.Inc()
+	if got, want := r.Rate(), 0.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	clock.Advance(time.Second)
+	if got, want := r.Rate(), 1.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestDecayCounter(t *testing.T) {
+	t.Run("half-life", func(t *testing.T) {
+		clock := newFakeClock()
+		d := NewDecayCounter(time.Minute, clock.Now)
+		parallel(10, func(int) {
+			for i := 0; i < 100; i++ {
+				d.Add(8)
+			}
+		})
+		// Simultaneous adds must not apply any decay to one another.
+		if got, want := d.Load(), 8000.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		clock.Advance(time.Minute)
+		if got, want := d.Load(), 4000.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		clock.Advance(time.Minute)
+		if got, want := d.Add(1000), 3000.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		// Loading must not itself decay the value.
+		d.Load()
+		clock.Advance(2 * time.Minute)
+		if got, want := d.Load(), 750.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("large-gap", func(t *testing.T) {
+		clock := newFakeClock()
+		d := NewDecayCounter(time.Nanosecond, clock.Now)
+		d.Add(math.MaxFloat64)
+		clock.Advance(100 * 365 * 24 * time.Hour)
+		if got, want := d.Load(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := d.Add(1), 1.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestTimestampedFloat(t *testing.T) {
+	t.Run("fresh", func(t *testing.T) {
+		clock := newFakeClock()
+		tf := NewTimestampedFloat(clock.Now)
+		if _, ok := tf.LoadIfFresh(time.Hour); ok {
+			t.Errorf("GOT: fresh; WANT: never set")
+		}
+
+		tf.Set(2.5)
+		v, at := tf.Load()
+		if got, want := v, 2.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := at, clock.Now(); !got.Equal(want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		clock.Advance(time.Second)
+		if v, ok := tf.LoadIfFresh(time.Second); !ok || v != 2.5 {
+			t.Errorf("GOT: %v, %v; WANT: 2.5, true", v, ok)
+		}
+		clock.Advance(time.Nanosecond)
+		if _, ok := tf.LoadIfFresh(time.Second); ok {
+			t.Errorf("GOT: fresh; WANT: stale")
+		}
+	})
+
+	t.Run("consistent", func(t *testing.T) {
+		// The clock ticks once per Set, and each writer sets the tick it
+		// observed beforehand, so every consistent pair has a timestamp
+		// after its value.
+		var ticks atomic.Int64
+		tf := NewTimestampedFloat(func() time.Time { return time.Unix(0, ticks.Add(1)) })
+		parallel(8, func(i int) {
+			for j := 0; j < 1000; j++ {
+				if i%2 == 0 {
+					tf.Set(float64(ticks.Load()))
+					continue
+				}
+				if v, at := tf.Load(); !at.IsZero() && float64(at.UnixNano()) <= v {
+					t.Errorf("GOT: %v at %v; WANT: timestamp after value", v, at.UnixNano())
+					return
+				}
+			}
+		})
+	})
+}
+
+func TestIntegrator(t *testing.T) {
+	clock := newFakeClock()
+	i := NewIntegrator(clock.Now)
+
+	// Nothing accumulates before the first Set.
+	clock.Advance(time.Hour)
+	if got, want := i.Integral(), 0.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	// Step integral: 10 for 2s, 4 for 500ms, 0 for 1s, then -2 for 3s.
+	i.Set(10)
+	clock.Advance(2 * time.Second)
+	if got, want := i.Integral(), 20.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	i.Set(4)
+	clock.Advance(500 * time.Millisecond)
+	i.Set(0)
+	clock.Advance(time.Second)
+	i.Set(-2)
+	clock.Advance(3 * time.Second)
+	if got, want := i.Integral(), 10*2+4*0.5+0*1-2*3.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := i.Level(), -2.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	// Long gaps accumulate in proportion.
+	i.Set(1)
+	clock.Advance(365 * 24 * time.Hour)
+	if got, want := i.Integral(), 16+365*24*3600.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	t.Run("concurrent", func(t *testing.T) {
+		clock := newFakeClock()
+		i := NewIntegrator(clock.Now)
+		i.Set(1)
+		parallel(10, func(int) {
+			for j := 0; j < 100; j++ {
+				i.Set(1)
+				clock.Advance(time.Millisecond)
+			}
+		})
+		if got, want := i.Integral(), 1.0; math.Abs(got-want) > 1e-9 {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestDerivative(t *testing.T) {
+	t.Run("linear", func(t *testing.T) {
+		clock := newFakeClock()
+		d := NewDerivative(clock.Now)
+		if got, want := d.Update(5), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		for i := 1; i <= 10; i++ {
+			clock.Advance(250 * time.Millisecond)
+			if got, want := d.Update(5+3*float64(i)*0.25), 3.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		}
+	})
+
+	t.Run("piecewise", func(t *testing.T) {
+		clock := newFakeClock()
+		d := NewDerivative(clock.Now)
+		d.Update(0)
+		for _, step := range []struct {
+			elapsed time.Duration
+			value   float64
+			rate    float64
+		}{
+			{time.Second, 10, 10},
+			{2 * time.Second, 10, 0},
+			{500 * time.Millisecond, 5, -10},
+			{0, 8, -10}, // same instant keeps the previous rate
+			{time.Second, 9, 1},
+		} {
+			clock.Advance(step.elapsed)
+			if got, want := d.Update(step.value), step.rate; got != want {
+				t.Errorf("%v: GOT: %v; WANT: %v", step.value, got, want)
+			}
+			if got, want := d.Rate(), step.rate; got != want {
+				t.Errorf("%v: GOT: %v; WANT: %v", step.value, got, want)
+			}
+		}
+	})
+}
+
+func TestTokenBucket(t *testing.T) {
+	t.Run("burst", func(t *testing.T) {
+		clock := newFakeClock()
+		b := NewTokenBucket(10, 5, clock.Now)
+		for i := 0; i < 5; i++ {
+			if !b.Allow() {
+				t.Fatalf("%d: GOT: false; WANT: true", i)
+			}
+		}
+		if b.Allow() {
+			t.Errorf("GOT: true; WANT: false")
+		}
+		if b.AllowN(6) {
+			t.Errorf("GOT: true; WANT: false")
+		}
+
+		// A long gap refills only up to the burst size.
+		clock.Advance(time.Hour)
+		if got, want := b.Tokens(), 5.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if !b.AllowN(4.5) {
+			t.Errorf("GOT: false; WANT: true")
+		}
+		if b.Allow() {
+			t.Errorf("GOT: true; WANT: false")
+		}
+		if got, want := b.Tokens(), 0.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		clock := newFakeClock()
+		b := NewTokenBucket(10, 5, clock.Now)
+		for _, n := range []float64{0, -100, math.NaN()} {
+			if b.AllowN(n) {
+				t.Errorf("%v: GOT: true; WANT: false", n)
+			}
+		}
+		if got, want := b.Tokens(), 5.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("steady", func(t *testing.T) {
+		clock := newFakeClock()
+		b := NewTokenBucket(4, 1, clock.Now)
+		b.Allow()
+		for i := 0; i < 10; i++ {
+			clock.Advance(125 * time.Millisecond)
+			if b.Allow() {
+				t.Errorf("%d: GOT: true; WANT: false after half a token", i)
+			}
+			clock.Advance(125 * time.Millisecond)
+			if !b.Allow() {
+				t.Errorf("%d: GOT: false; WANT: true after a whole token", i)
+			}
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// Over the window, concurrent callers may draw no more than the
+		// initial burst plus what was refilled.
+		const rate, burst = 1000, 50
+		clock := newFakeClock()
+		b := NewTokenBucket(rate, burst, clock.Now)
+		var grants atomic.Int64
+		parallel(10, func(int) {
+			for i := 0; i < 1000; i++ {
+				if b.Allow() {
+					grants.Add(1)
+				}
+				clock.Advance(10 * time.Microsecond)
+			}
+		})
+		window := 10 * 1000 * 10 * time.Microsecond
+		if got, max := grants.Load(), int64(burst+rate*window.Seconds()); got > max || got < burst {
+			t.Errorf("GOT: %v; WANT: between %v and %v", got, burst, max)
+		}
+	})
+}
+
+func TestLeakyBucket(t *testing.T) {
+	t.Run("steady", func(t *testing.T) {
+		// Pouring 3 every 100ms into a bucket that leaks 10 per second
+		// overflows 2 every 100ms once it is full.
+		clock := newFakeClock()
+		b := NewLeakyBucket(10, 5, clock.Now)
+		var overflow float64
+		for i := 0; i < 5; i++ {
+			overflow += b.Add(3)
+			clock.Advance(100 * time.Millisecond)
+		}
+		for i := 0; i < 10; i++ {
+			if got, want := b.Add(3), 2.0; math.Abs(got-want) > 1e-9 {
+				t.Errorf("%d: GOT: %v; WANT: %v", i, got, want)
+			}
+			clock.Advance(100 * time.Millisecond)
+		}
+		if got, want := overflow, 0+0+2+2+2.0; math.Abs(got-want) > 1e-9 { // full after two adds
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		// The bucket empties once input stops.
+		clock.Advance(time.Hour)
+		if got, want := b.Level(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := b.Add(-1), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("nan", func(t *testing.T) {
+		clock := newFakeClock()
+		b := NewLeakyBucket(10, 5, clock.Now)
+		b.Add(3)
+		if got := b.Add(math.NaN()); !math.IsNaN(got) {
+			t.Errorf("GOT: %v; WANT: NaN", got)
+		}
+		if got, want := b.Level(), 3.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := b.Add(4), 2.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// With the clock stopped nothing leaks, so everything poured in
+		// either remains or overflowed, and the level never exceeds
+		// capacity.
+		const rate, capacity = 100, 10
+		clock := newFakeClock()
+		b := NewLeakyBucket(rate, capacity, clock.Now)
+		if got, want := b.Level(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		var overflow atomicFloatCAS
+		parallel(10, func(int) {
+			for i := 0; i < 1000; i++ {
+				overflow.Add(b.Add(0.25))
+				if level := b.Level(); level > capacity {
+					t.Errorf("GOT: %v; WANT: at most %v", level, capacity)
+					return
+				}
+			}
+		})
+		if got, want := overflow.Load()+b.Level(), 10*1000*0.25; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
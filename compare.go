@@ -0,0 +1,60 @@
+package atomic
+
+import "math"
+
+// Equal loads the values of a and b and returns true when they are within the
+// combined tolerance, that is, when |a-b| <= max(absTol, relTol*max(|a|,|b|)).
+// NaN is never equal to anything, including NaN, and ±Inf is only equal to the
+// same infinity, regardless of the tolerances.
+func Equal(a, b AtomicFloat, absTol, relTol float64) bool {
+	x, y := a.Load(), b.Load()
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return false
+	}
+	if math.IsInf(x, 0) || math.IsInf(y, 0) {
+		return x == y
+	}
+	return math.Abs(x-y) <= math.Max(absTol, relTol*math.Max(math.Abs(x), math.Abs(y)))
+}
+
+// ULPDistance returns the number of representable float64 values between a and
+// b, in units in the last place, so adjacent values are 1 apart and +0 and -0
+// are 0 apart. Distances too great to represent, and any distance involving
+// NaN, are math.MaxInt64.
+func ULPDistance(a, b float64) int64 {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.MaxInt64
+	}
+	x, y := orderedBits(a), orderedBits(b)
+	if x < y {
+		x, y = y, x
+	}
+	// Subtract as unsigned, since values of opposite sign may be more than
+	// math.MaxInt64 apart.
+	if d := uint64(x) - uint64(y); d < math.MaxInt64 {
+		return int64(d)
+	}
+	return math.MaxInt64
+}
+
+// orderedBits maps f to an integer whose ordering matches that of f, by
+// reflecting the sign-magnitude representation of negative values, so that -0
+// and +0 both map to 0.
+func orderedBits(f float64) int64 {
+	i := int64(math.Float64bits(f))
+	if i < 0 {
+		i = math.MinInt64 - i
+	}
+	return i
+}
+
+// EqualULP loads the values of a and b and returns true when they are within
+// maxULP units in the last place of one another. NaN is never equal to
+// anything, including NaN.
+func EqualULP(a, b AtomicFloat, maxULP int64) bool {
+	x, y := a.Load(), b.Load()
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return false
+	}
+	return ULPDistance(x, y) <= maxULP
+}
@@ -0,0 +1,83 @@
+package atomic
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	inf, nan := math.Inf(1), math.NaN()
+	cases := []struct {
+		a, b, absTol, relTol float64
+		want                 bool
+	}{
+		{1, 1, 0, 0, true},
+		{1, 1.5, 0.5, 0, true},   // on the absolute boundary
+		{1, 1.5, 0.49, 0, false}, // just outside it
+		{100, 110, 0, 0.09, false},
+		{100, 110, 0, 1.0 / 11, true}, // relative to the larger magnitude
+		{1e-20, -1e-20, 1e-19, 1e-9, true},
+		{0, math.Copysign(0, -1), 0, 0, true},
+		{nan, nan, inf, inf, false},
+		{nan, 1, inf, inf, false},
+		{inf, inf, 0, 0, true},
+		{-inf, -inf, 0, 0, true},
+		{inf, -inf, inf, inf, false},
+		{inf, math.MaxFloat64, inf, inf, false},
+	}
+	for _, c := range cases {
+		a, b := NewAtomicFloatCAS(c.a), NewAtomicFloatMutex(c.b)
+		if got, want := Equal(a, b, c.absTol, c.relTol), c.want; got != want {
+			t.Errorf("%v, %v: GOT: %v; WANT: %v", c.a, c.b, got, want)
+		}
+		if got, want := Equal(b, a, c.absTol, c.relTol), c.want; got != want {
+			t.Errorf("%v, %v: GOT: %v; WANT: %v", c.b, c.a, got, want)
+		}
+	}
+}
+
+func TestULPDistance(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+	cases := []struct {
+		a, b float64
+		want int64
+	}{
+		{1, 1, 0},
+		{1, math.Nextafter(1, 2), 1},
+		{-1, math.Nextafter(-1, -2), 1},
+		{0, negZero, 0},
+		{0, math.SmallestNonzeroFloat64, 1},
+		{negZero, math.SmallestNonzeroFloat64, 1},
+		{-math.SmallestNonzeroFloat64, math.SmallestNonzeroFloat64, 2},
+		{math.MaxFloat64, math.Inf(1), 1},
+		{1, 2, 1 << 52},
+		{-math.MaxFloat64, math.MaxFloat64, math.MaxInt64},
+		{math.Inf(-1), math.Inf(1), math.MaxInt64},
+		{math.NaN(), math.NaN(), math.MaxInt64},
+		{math.NaN(), 1, math.MaxInt64},
+	}
+	for _, c := range cases {
+		if got, want := ULPDistance(c.a, c.b), c.want; got != want {
+			t.Errorf("%v, %v: GOT: %v; WANT: %v", c.a, c.b, got, want)
+		}
+		if got, want := ULPDistance(c.b, c.a), c.want; got != want {
+			t.Errorf("%v, %v: GOT: %v; WANT: %v", c.b, c.a, got, want)
+		}
+	}
+
+	t.Run("EqualULP", func(t *testing.T) {
+		a := NewAtomicFloatCAS(0.3)
+		b := NewAtomicFloatCAS(0.1)
+		b.Add(0.2) // 0.30000000000000004, one ULP above 0.3
+		if got, want := EqualULP(a, b, 0), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := EqualULP(a, b, 1), true; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		nan := NewAtomicFloatCAS(math.NaN())
+		if got, want := EqualULP(nan, nan, math.MaxInt64), false; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
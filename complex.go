@@ -0,0 +1,74 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// atomicComplex128 is an atomic complex number, such as for accumulating
+// complex amplitudes from multiple goroutines. Its real and imaginary parts are
+// stored in two words, which no single atomic instruction can update together,
+// so they are guarded by a seqlock. Readers therefore never observe the real
+// part of one write paired with the imaginary part of another, and never block
+// writers, but writers exclude one another.
+type atomicComplex128 struct {
+	sl seqlock
+	re atomic.Uint64
+	im atomic.Uint64
+}
+
+func NewAtomicComplex128(initial complex128) *atomicComplex128 {
+	a := new(atomicComplex128)
+	a.re.Store(math.Float64bits(real(initial)))
+	a.im.Store(math.Float64bits(imag(initial)))
+	return a
+}
+
+// load returns the current value. It must be called while holding the write
+// claim, or validated against the sequence number.
+func (a *atomicComplex128) load() complex128 {
+	return complex(math.Float64frombits(a.re.Load()), math.Float64frombits(a.im.Load()))
+}
+
+// store stores new. It must be called while holding the write claim.
+func (a *atomicComplex128) store(new complex128) {
+	a.re.Store(math.Float64bits(real(new)))
+	a.im.Store(math.Float64bits(imag(new)))
+}
+
+// Add atomically adds delta to the value stored in the atomic complex number
+// and returns the new value.
+func (a *atomicComplex128) Add(delta complex128) complex128 {
+	a.sl.lock()
+	new := a.load() + delta
+	a.store(new)
+	a.sl.unlock()
+	return new
+}
+
+// Load atomically loads the current atomic complex number value.
+func (a *atomicComplex128) Load() complex128 {
+	for {
+		seq := a.sl.beginRead()
+		v := a.load()
+		if a.sl.validRead(seq) {
+			return v
+		}
+	}
+}
+
+// Store atomically stores new into the atomic complex number.
+func (a *atomicComplex128) Store(new complex128) {
+	a.sl.lock()
+	a.store(new)
+	a.sl.unlock()
+}
+
+// Swap atomically stores new and returns the previous value.
+func (a *atomicComplex128) Swap(new complex128) complex128 {
+	a.sl.lock()
+	old := a.load()
+	a.store(new)
+	a.sl.unlock()
+	return old
+}
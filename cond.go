@@ -0,0 +1,194 @@
+package atomic
+
+import (
+	"context"
+	"sync"
+)
+
+// atomicFloatCond is a mutex-backed atomic float whose writers broadcast on a
+// condition variable, allowing goroutines to block until the value satisfies
+// some condition, such as a running total reaching a threshold, and notify
+// subscribers and observers of each new value.
+type atomicFloatCond struct {
+	f64         float64
+	l           sync.Mutex
+	cond        sync.Cond
+	subscribers map[<-chan float64]chan float64
+	observers   []*func(new float64) // replaced, never modified, when changed
+}
+
+func NewAtomicFloatCond(initial float64) *atomicFloatCond {
+	a := &atomicFloatCond{f64: initial}
+	a.cond.L = &a.l
+	return a
+}
+
+// Add attempts to add delta to the value stored in the atomic float and return
+// the new value, waking any waiters.
+func (a *atomicFloatCond) Add(delta float64) float64 {
+	a.l.Lock()
+	a.f64 += delta
+	new := a.f64
+	observers := a.changed()
+	a.l.Unlock()
+	notify(observers, new)
+	return new
+}
+
+// Load atomically loads the current atomic float value.
+func (a *atomicFloatCond) Load() float64 {
+	a.l.Lock()
+	v := a.f64
+	a.l.Unlock()
+	return v
+}
+
+// Store atomically stores new into the atomic float, waking any waiters.
+func (a *atomicFloatCond) Store(new float64) {
+	a.l.Lock()
+	a.f64 = new
+	observers := a.changed()
+	a.l.Unlock()
+	notify(observers, new)
+}
+
+// Swap atomically stores new and returns the previous value, waking any
+// waiters.
+func (a *atomicFloatCond) Swap(new float64) float64 {
+	a.l.Lock()
+	old := a.f64
+	a.f64 = new
+	observers := a.changed()
+	a.l.Unlock()
+	notify(observers, new)
+	return old
+}
+
+// changed wakes every waiter and notifies every subscriber of the new value,
+// and returns the observers to be notified once the lock is released. It must
+// be called while holding the lock.
+func (a *atomicFloatCond) changed() []*func(new float64) {
+	a.cond.Broadcast()
+	for _, ch := range a.subscribers {
+		// Each channel buffers a single value, so rather than blocking
+		// the writer on a slow subscriber, replace its unreceived value
+		// with the latest one.
+		select {
+		case ch <- a.f64:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- a.f64:
+			default:
+			}
+		}
+	}
+	return a.observers
+}
+
+// Subscribe returns a channel that receives the new value after each write to
+// the atomic float. Writers never block on a subscriber: when a subscriber has
+// not received the previous value by the time of the next write, the previous
+// value is discarded in favor of the next, so a slow subscriber observes only
+// the most recent value rather than every one.
+func (a *atomicFloatCond) Subscribe() <-chan float64 {
+	ch := make(chan float64, 1)
+	a.l.Lock()
+	if a.subscribers == nil {
+		a.subscribers = make(map[<-chan float64]chan float64)
+	}
+	a.subscribers[ch] = ch
+	a.l.Unlock()
+	return ch
+}
+
+// Unsubscribe stops delivery of values to ch, which must have been returned by
+// Subscribe, and closes it. Unsubscribing a channel more than once has no
+// effect.
+func (a *atomicFloatCond) Unsubscribe(ch <-chan float64) {
+	a.l.Lock()
+	if c, ok := a.subscribers[ch]; ok {
+		delete(a.subscribers, ch)
+		close(c)
+	}
+	a.l.Unlock()
+}
+
+// AddObserver registers fn to be called with the new value after each write to
+// the atomic float, and returns a function that removes it. Observers are
+// called on the writer's goroutine, after the write has released the lock, so
+// they may read or even write the atomic float, but they delay the writer, so
+// they should be fast. Concurrent writers call observers concurrently, so an
+// observer may be called with values out of order.
+func (a *atomicFloatCond) AddObserver(fn func(new float64)) (cancel func()) {
+	p := &fn
+	a.l.Lock()
+	a.observers = append(a.observers[:len(a.observers):len(a.observers)], p)
+	a.l.Unlock()
+	return func() {
+		a.l.Lock()
+		for i, o := range a.observers {
+			if o == p {
+				observers := make([]*func(new float64), 0, len(a.observers)-1)
+				a.observers = append(append(observers, a.observers[:i]...), a.observers[i+1:]...)
+				break
+			}
+		}
+		a.l.Unlock()
+	}
+}
+
+// notify calls each observer with new.
+func notify(observers []*func(new float64), new float64) {
+	for _, fn := range observers {
+		(*fn)(new)
+	}
+}
+
+// WaitForValue blocks until cmp returns true for the value stored in the atomic
+// float and threshold, and returns that value. cmp is called while holding the
+// lock, initially and after every write, so it must be fast and must not call
+// back into the atomic float. A nil cmp waits for the value to be greater than
+// or equal to threshold.
+func (a *atomicFloatCond) WaitForValue(threshold float64, cmp func(cur, threshold float64) bool) float64 {
+	if cmp == nil {
+		cmp = atLeast
+	}
+	a.l.Lock()
+	for !cmp(a.f64, threshold) {
+		a.cond.Wait()
+	}
+	v := a.f64
+	a.l.Unlock()
+	return v
+}
+
+// WaitForValueContext blocks until the value stored in the atomic float is
+// greater than or equal to threshold, and returns that value, or until ctx is
+// done, and returns the value at that time along with the context's error.
+func (a *atomicFloatCond) WaitForValueContext(ctx context.Context, threshold float64) (float64, error) {
+	// Condition variables cannot select on a channel, so wake every waiter
+	// when ctx is done, so this one may notice.
+	stop := context.AfterFunc(ctx, func() {
+		a.l.Lock()
+		a.cond.Broadcast()
+		a.l.Unlock()
+	})
+	defer stop()
+
+	a.l.Lock()
+	defer a.l.Unlock()
+	for !atLeast(a.f64, threshold) {
+		if err := ctx.Err(); err != nil {
+			return a.f64, err
+		}
+		a.cond.Wait()
+	}
+	return a.f64, nil
+}
+
+// atLeast returns true when cur is greater than or equal to threshold.
+func atLeast(cur, threshold float64) bool { return cur >= threshold }
@@ -0,0 +1,173 @@
+package atomic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWaitForValue(t *testing.T) {
+	t.Run("waiters", func(t *testing.T) {
+		a := NewAtomicFloatCond(0)
+		released := make(chan float64)
+		for i := 0; i < 5; i++ {
+			go func() { released <- a.WaitForValue(10, nil) }()
+		}
+		go func() {
+			for i := 0; i < 10; i++ {
+				a.Add(1.5)
+			}
+		}()
+		for i := 0; i < 5; i++ {
+			if got := <-released; got < 10 {
+				t.Errorf("GOT: %v; WANT: >= 10", got)
+			}
+		}
+	})
+
+	t.Run("cmp", func(t *testing.T) {
+		a := NewAtomicFloatCond(5)
+		done := make(chan float64)
+		go func() {
+			done <- a.WaitForValue(0, func(cur, threshold float64) bool { return cur < threshold })
+		}()
+		a.Store(1)
+		a.Swap(-1)
+		if got, want := <-done, -1.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("context", func(t *testing.T) {
+		a := NewAtomicFloatCond(0)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			_, err := a.WaitForValueContext(ctx, 10)
+			done <- err
+		}()
+		a.Add(1)
+		cancel()
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("GOT: %v; WANT: %v", err, context.Canceled)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("GOT: waiter still blocked; WANT: canceled")
+		}
+
+		v, err := a.WaitForValueContext(context.Background(), 1)
+		if got, want := v, 1.0; err != nil || got != want {
+			t.Errorf("GOT: %v, %v; WANT: %v, nil", got, err, want)
+		}
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	a := NewAtomicFloatCond(0)
+	slow, fast := a.Subscribe(), a.Subscribe()
+
+	a.Store(1)
+	if got, want := <-fast, 1.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	// The slow subscriber has not received anything yet, so it observes
+	// only the latest value, and writers never block on it.
+	for i := 0; i < 100; i++ {
+		a.Add(1)
+	}
+	a.Swap(-5)
+	if got, want := <-slow, -5.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := <-fast, -5.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	a.Unsubscribe(fast)
+	a.Unsubscribe(fast)
+	a.Store(7)
+	if v, ok := <-fast; ok {
+		t.Errorf("GOT: %v; WANT: closed", v)
+	}
+	if got, want := <-slow, 7.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestAddObserver(t *testing.T) {
+	a := NewAtomicFloatCond(0)
+	var first, second []float64
+	record := func(values *[]float64) func(float64) {
+		return func(new float64) {
+			*values = append(*values, new)
+			a.Load() // observers run without the lock held
+		}
+	}
+	cancelFirst := a.AddObserver(record(&first))
+	cancelSecond := a.AddObserver(record(&second))
+	a.Add(1)
+	a.Store(5)
+
+	// Registering the same observer twice calls it twice, and each cancel
+	// removes exactly one registration.
+	cancelAgain := a.AddObserver(record(&first))
+	a.Swap(2)
+	cancelFirst()
+	cancelFirst()
+	a.Add(1)
+	cancelAgain()
+	cancelSecond()
+	a.Add(1)
+
+	if got, want := fmt.Sprint(first), "[1 5 2 2 3]"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := fmt.Sprint(second), "[1 5 2 3]"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestAddContext(t *testing.T) {
+	a := NewAtomicFloatContextMutex(1)
+	parallel(10, func(int) {
+		for i := 0; i < 100; i++ {
+			if _, err := a.AddContext(context.Background(), 1); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	})
+	if got, want := a.Load(), 1001.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		a.lock() // hold the lock so the add must wait
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := a.AddContext(ctx, 1)
+		a.unlock()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("GOT: %v; WANT: %v", err, context.DeadlineExceeded)
+		}
+		if got, want := a.Load(), 1001.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := a.AddContext(ctx, 1); !errors.Is(err, context.Canceled) {
+			t.Errorf("GOT: %v; WANT: %v", err, context.Canceled)
+		}
+		if got, want := a.Load(), 1001.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package atomic
+
+// ToCAS returns a new atomicFloatCAS initialized to the value atomically
+// loaded from af. The result is a point-in-time copy rather than a live view,
+// so subsequent updates to either atomic float are not reflected in the other.
+func ToCAS(af AtomicFloat) *atomicFloatCAS {
+	return NewAtomicFloatCAS(af.Load())
+}
+
+// ToCAS2 returns a new atomicFloatCAS2 initialized to the value atomically
+// loaded from af. Like ToCAS, the result is a point-in-time copy.
+func ToCAS2(af AtomicFloat) *atomicFloatCAS2 {
+	return NewAtomicFloatCAS2(af.Load())
+}
+
+// ToMutex returns a new atomicFloatMutex initialized to the value atomically
+// loaded from af. Like ToCAS, the result is a point-in-time copy.
+func ToMutex(af AtomicFloat) *atomicFloatMutex {
+	return NewAtomicFloatMutex(af.Load())
+}
+
+// ToSharded returns a new atomicFloatSharded initialized to the value loaded
+// from af. Like ToCAS, the result is a point-in-time copy.
+func ToSharded(af AtomicFloat) *atomicFloatSharded {
+	return NewAtomicFloatSharded(af.Load())
+}
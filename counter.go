@@ -0,0 +1,73 @@
+package atomic
+
+import "math"
+
+// Counter is an atomic float counter that never drops below zero, such as for
+// tracking the number of requests in flight, where a value below zero would
+// only ever be the result of a bug. Attempts to remove more than the counter
+// holds empty it instead.
+type Counter struct {
+	af atomicFloatCAS
+}
+
+// NewCounter returns a Counter initialized to initial, or to zero when initial
+// is negative or NaN.
+func NewCounter(initial float64) *Counter {
+	c := new(Counter)
+	if initial > 0 {
+		c.af.Store(initial)
+	}
+	return c
+}
+
+// add atomically adds delta to the counter, flooring the sum at zero, and
+// returns the old and new values. When the sum is NaN, such as when delta is
+// NaN, the counter is left unchanged.
+func (c *Counter) add(delta float64) (float64, float64) {
+	for {
+		oldBits := c.af.u64.Load()
+		oldValue := math.Float64frombits(oldBits)
+		newValue := oldValue + delta
+		if newValue != newValue {
+			return oldValue, oldValue // NaN
+		}
+		newValue = math.Max(newValue, 0)
+		if c.af.u64.CompareAndSwap(oldBits, math.Float64bits(newValue)) {
+			return oldValue, newValue
+		}
+	}
+}
+
+// Add atomically adds delta to the counter and returns the new value. When
+// delta is negative and larger in magnitude than the counter, the counter is
+// emptied. A NaN delta is ignored.
+func (c *Counter) Add(delta float64) float64 {
+	_, newValue := c.add(delta)
+	return newValue
+}
+
+// Inc atomically increments the counter by one and returns the new value.
+func (c *Counter) Inc() float64 {
+	_, newValue := c.add(1)
+	return newValue
+}
+
+// Sub atomically subtracts delta from the counter and returns the amount
+// actually removed, which is less than delta when the counter held less than
+// delta, and zero when delta is NaN.
+func (c *Counter) Sub(delta float64) float64 {
+	oldValue, newValue := c.add(-delta)
+	return oldValue - newValue
+}
+
+// Dec atomically decrements the counter by one and returns the amount actually
+// removed, which is less than one when the counter held less than one.
+func (c *Counter) Dec() float64 {
+	oldValue, newValue := c.add(-1)
+	return oldValue - newValue
+}
+
+// Load atomically loads the current counter value.
+func (c *Counter) Load() float64 {
+	return c.af.Load()
+}
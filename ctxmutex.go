@@ -0,0 +1,71 @@
+package atomic
+
+import "context"
+
+// atomicFloatContextMutex is a mutex-backed atomic float whose lock is a
+// channel with a buffer of one, so that waiting for it can be abandoned when a
+// context is done, which sync.Mutex does not allow.
+type atomicFloatContextMutex struct {
+	f64 float64
+	sem chan struct{} // holds a value while locked
+}
+
+func NewAtomicFloatContextMutex(initial float64) *atomicFloatContextMutex {
+	return &atomicFloatContextMutex{f64: initial, sem: make(chan struct{}, 1)}
+}
+
+func (a *atomicFloatContextMutex) lock()   { a.sem <- struct{}{} }
+func (a *atomicFloatContextMutex) unlock() { <-a.sem }
+
+// Add attempts to add delta to the value stored in the atomic float and return
+// the new value.
+func (a *atomicFloatContextMutex) Add(delta float64) float64 {
+	a.lock()
+	a.f64 += delta
+	new := a.f64
+	a.unlock()
+	return new
+}
+
+// AddContext adds delta to the value stored in the atomic float and returns the
+// new value, unless ctx is done before the lock is acquired, in which case the
+// stored value is left untouched and AddContext returns zero and the context's
+// error. A ctx that is already done never acquires the lock.
+func (a *atomicFloatContextMutex) AddContext(ctx context.Context, delta float64) (float64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	select {
+	case a.sem <- struct{}{}:
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	a.f64 += delta
+	new := a.f64
+	a.unlock()
+	return new, nil
+}
+
+// Load atomically loads the current atomic float value.
+func (a *atomicFloatContextMutex) Load() float64 {
+	a.lock()
+	v := a.f64
+	a.unlock()
+	return v
+}
+
+// Store atomically stores new into the atomic float.
+func (a *atomicFloatContextMutex) Store(new float64) {
+	a.lock()
+	a.f64 = new
+	a.unlock()
+}
+
+// Swap atomically stores new and returns the previous value.
+func (a *atomicFloatContextMutex) Swap(new float64) float64 {
+	a.lock()
+	old := a.f64
+	a.f64 = new
+	a.unlock()
+	return old
+}
@@ -0,0 +1,69 @@
+package atomic
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// decayState is an immutable snapshot of a DecayCounter's value as of the time
+// it was last updated.
+type decayState struct {
+	value float64
+	at    time.Time
+}
+
+// DecayCounter is a concurrency-safe counter whose value decays exponentially
+// with the passage of time, forgetting old activity, such as for adaptive load
+// shedding. The value and the time it was last updated are swapped together
+// through a pointer to a freshly allocated snapshot, so every Add allocates.
+type DecayCounter struct {
+	p        atomic.Pointer[decayState]
+	halfLife float64 // in seconds
+	now      func() time.Time
+}
+
+// NewDecayCounter returns a new DecayCounter whose value halves every halfLife,
+// reading the current time by calling now, or time.Now when now is nil. It
+// panics when halfLife is not positive.
+func NewDecayCounter(halfLife time.Duration, now func() time.Time) *DecayCounter {
+	if halfLife <= 0 {
+		panic(fmt.Sprintf("atomic: NewDecayCounter half-life must be positive: %v", halfLife))
+	}
+	d := &DecayCounter{halfLife: halfLife.Seconds(), now: clockOrDefault(now)}
+	d.p.Store(&decayState{at: d.now()})
+	return d
+}
+
+// decayed returns the value of s decayed from when it was last updated until
+// now. Time that appears to run backwards does not decay the value. Very large
+// gaps decay the value to zero.
+func (d *DecayCounter) decayed(s *decayState, now time.Time) float64 {
+	elapsed := now.Sub(s.at).Seconds()
+	if elapsed <= 0 {
+		return s.value
+	}
+	return s.value * math.Exp2(-elapsed/d.halfLife)
+}
+
+// Add atomically decays the value to the current time, adds x to it, and
+// returns the new value.
+func (d *DecayCounter) Add(x float64) float64 {
+	for {
+		old := d.p.Load()
+		now := d.now()
+		if now.Before(old.at) {
+			now = old.at
+		}
+		next := &decayState{value: d.decayed(old, now) + x, at: now}
+		if d.p.CompareAndSwap(old, next) {
+			return next.value
+		}
+	}
+}
+
+// Load returns the value decayed to the current time.
+func (d *DecayCounter) Load() float64 {
+	return d.decayed(d.p.Load(), d.now())
+}
@@ -0,0 +1,63 @@
+package atomic
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// derivativeState is an immutable snapshot of a Derivative's most recent
+// value, the time it was recorded, and the rate computed then.
+type derivativeState struct {
+	value float64
+	rate  float64
+	at    time.Time
+}
+
+// Derivative is a concurrency-safe estimator of the instantaneous rate at
+// which a value changes, in units of the value per second, computed as the
+// slope between successive updates. The previous value, its time, and the rate
+// are swapped together through a pointer to a freshly allocated snapshot, so
+// every Update allocates.
+type Derivative struct {
+	p   atomic.Pointer[derivativeState] // nil until the first Update
+	now func() time.Time
+}
+
+// NewDerivative returns a new Derivative that has never been updated, reading
+// the current time by calling now, or time.Now when now is nil.
+func NewDerivative(now func() time.Time) *Derivative {
+	return &Derivative{now: clockOrDefault(now)}
+}
+
+// Update atomically records v as the current value and returns the rate of
+// change since the previous update, (v - prev) / dt. The first update returns
+// 0, since there is no previous value. When no time has elapsed since the
+// previous update, as for two updates in the same instant, or when time
+// appears to run backwards, the slope is undefined, so Update records v as the
+// value at the previous instant and returns the previous rate unchanged.
+func (d *Derivative) Update(v float64) float64 {
+	for {
+		old := d.p.Load()
+		now := d.now()
+		next := &derivativeState{value: v, at: now}
+		if old != nil {
+			if dt := now.Sub(old.at).Seconds(); dt > 0 {
+				next.rate = (v - old.value) / dt
+			} else {
+				next.rate, next.at = old.rate, old.at
+			}
+		}
+		if d.p.CompareAndSwap(old, next) {
+			return next.rate
+		}
+	}
+}
+
+// Rate atomically loads the rate computed by the most recent update, or 0 when
+// there have been fewer than two updates.
+func (d *Derivative) Rate() float64 {
+	if s := d.p.Load(); s != nil {
+		return s.rate
+	}
+	return 0
+}
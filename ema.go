@@ -0,0 +1,65 @@
+package atomic
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+)
+
+// Sentinel bit patterns in this package are signaling NaNs, which floating
+// point arithmetic never produces, so only a caller supplying that exact NaN
+// can collide with one, and the types using them quiet such a value.
+
+// emaUnset is the bit pattern of an EMA that has not yet been seeded by a
+// sample. It is a signaling NaN sentinel.
+const emaUnset = 0x7ff4000000000000
+
+// EMA is a concurrency-safe exponential moving average.
+type EMA struct {
+	u64   atomic.Uint64
+	alpha float64
+}
+
+// NewEMA returns an exponential moving average that gives weight alpha to each
+// new sample and weight 1-alpha to the existing average. It panics unless alpha
+// is in the half-open interval (0, 1].
+func NewEMA(alpha float64) *EMA {
+	if !(alpha > 0 && alpha <= 1) {
+		panic(fmt.Sprintf("atomic: NewEMA alpha must be in (0, 1]: %v", alpha))
+	}
+	e := &EMA{alpha: alpha}
+	e.u64.Store(emaUnset)
+	return e
+}
+
+// Add atomically blends sample into the moving average and returns the new
+// average. The first sample seeds the average rather than being blended with
+// zero.
+func (e *EMA) Add(sample float64) float64 {
+	var newValue float64
+	var oldBits, newBits uint64
+	for {
+		oldBits = e.u64.Load()
+		if oldBits == emaUnset {
+			newValue = sample
+		} else {
+			newValue = e.alpha*sample + (1-e.alpha)*math.Float64frombits(oldBits)
+		}
+		if newBits = math.Float64bits(newValue); newBits == emaUnset {
+			newBits = math.Float64bits(math.NaN())
+		}
+		if e.u64.CompareAndSwap(oldBits, newBits) {
+			return newValue
+		}
+	}
+}
+
+// Load atomically loads the current moving average, or NaN when no sample has
+// yet been added.
+func (e *EMA) Load() float64 {
+	bits := e.u64.Load()
+	if bits == emaUnset {
+		return math.NaN()
+	}
+	return math.Float64frombits(bits)
+}
@@ -0,0 +1,84 @@
+package atomic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// appendJSONFloat appends the JSON encoding of f to b. Because JSON cannot
+// represent NaN or ±Inf, those values are encoded as null.
+func appendJSONFloat(b []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return append(b, "null"...)
+	}
+	return strconv.AppendFloat(b, f, 'g', -1, 64)
+}
+
+// parseJSONFloat parses b as a JSON number. It returns false without error when
+// b is the JSON null literal, which by convention leaves the destination
+// unchanged.
+func parseJSONFloat(b []byte) (float64, bool, error) {
+	if !json.Valid(b) {
+		return 0, false, fmt.Errorf("atomic: cannot unmarshal invalid JSON %q", b)
+	}
+	if string(b) == "null" {
+		return 0, false, nil
+	}
+	f, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, false, fmt.Errorf("atomic: cannot unmarshal JSON number %s: out of float64 range", b)
+		}
+		return 0, false, fmt.Errorf("atomic: cannot unmarshal JSON %s into atomic float: not a number", b)
+	}
+	return f, true, nil
+}
+
+// parseTextFloat parses b as a floating point number, as formatted by
+// strconv.FormatFloat, including NaN and ±Inf.
+func parseTextFloat(b []byte) (float64, error) {
+	f, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("atomic: cannot unmarshal text %q: out of float64 range", b)
+		}
+		return 0, fmt.Errorf("atomic: cannot unmarshal text %q into atomic float: not a number", b)
+	}
+	return f, nil
+}
+
+// parseSQLFloat converts the database value src to a float64. It returns false
+// without error when src is NULL.
+func parseSQLFloat(src any) (float64, bool, error) {
+	switch v := src.(type) {
+	case nil:
+		return 0, false, nil
+	case float64:
+		return v, true, nil
+	case int64:
+		return float64(v), true, nil
+	case []byte:
+		f, err := parseTextFloat(v)
+		return f, err == nil, err
+	case string:
+		f, err := parseTextFloat([]byte(v))
+		return f, err == nil, err
+	default:
+		return 0, false, fmt.Errorf("atomic: cannot scan %T into atomic float", src)
+	}
+}
+
+// errNilUnmarshal returns the error for an unmarshal method invoked on a nil
+// pointer.
+func errNilUnmarshal(method string) error {
+	return fmt.Errorf("atomic: %s on nil pointer", method)
+}
+
+// errBinaryLength returns the error for binary encoded input of the wrong
+// length.
+func errBinaryLength(n int) error {
+	return fmt.Errorf("atomic: cannot unmarshal %d bytes of binary data: want 8", n)
+}
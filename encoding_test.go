@@ -0,0 +1,357 @@
+package atomic
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"expvar"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	type jsonFloat interface {
+		AtomicFloat
+		json.Marshaler
+		json.Unmarshaler
+	}
+
+	t.Run("marshal", func(t *testing.T) {
+		cases := []struct {
+			value float64
+			want  string
+		}{
+			{0, "0"},
+			{-1.5, "-1.5"},
+			{1e21, "1e+21"},
+			{math.NaN(), "null"},
+			{math.Inf(1), "null"},
+			{math.Inf(-1), "null"},
+		}
+		for _, c := range cases {
+			eachImplementation(t, c.value, func(t *testing.T, af AtomicFloat) {
+				buf, err := json.Marshal(struct{ V jsonFloat }{af.(jsonFloat)})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, want := string(buf), `{"V":`+c.want+`}`; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+		}
+	})
+
+	t.Run("unmarshal", func(t *testing.T) {
+		eachImplementation(t, 7, func(t *testing.T, af AtomicFloat) {
+			v := struct{ V jsonFloat }{af.(jsonFloat)}
+			if err := json.Unmarshal([]byte(`{"V":-0.25}`), &v); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := af.Load(), -0.25; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			if err := json.Unmarshal([]byte(`{"V":null}`), &v); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := af.Load(), -0.25; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		for _, input := range []string{`"NaN"`, `NaN`, `Infinity`, `1e400`, `-1e400`, `true`, `[1]`} {
+			eachImplementation(t, 7, func(t *testing.T, af AtomicFloat) {
+				if err := af.(jsonFloat).UnmarshalJSON([]byte(input)); err == nil {
+					t.Errorf("%s: GOT: nil; WANT: error", input)
+				}
+				if got, want := af.Load(), 7.0; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		for _, u := range []json.Unmarshaler{(*atomicFloatCAS)(nil), (*atomicFloatCAS2)(nil), (*atomicFloatMutex)(nil)} {
+			if err := u.UnmarshalJSON([]byte("1")); err == nil {
+				t.Errorf("GOT: nil; WANT: error")
+			}
+		}
+	})
+}
+
+func TestText(t *testing.T) {
+	type textFloat interface {
+		AtomicFloat
+		encoding.TextMarshaler
+		encoding.TextUnmarshaler
+	}
+
+	t.Run("round-trip", func(t *testing.T) {
+		for _, value := range []float64{
+			0,
+			math.Copysign(0, -1),
+			math.SmallestNonzeroFloat64,
+			-math.SmallestNonzeroFloat64 * 12345, // subnormal
+			0x1p-1022,                            // smallest normal
+			math.MaxFloat64,
+			0.1,
+			math.Inf(1),
+			math.Inf(-1),
+			math.NaN(),
+		} {
+			eachImplementation(t, value, func(t *testing.T, af AtomicFloat) {
+				buf, err := af.(textFloat).MarshalText()
+				if err != nil {
+					t.Fatal(err)
+				}
+				other := NewAtomicFloatCAS(42)
+				if err := other.UnmarshalText(buf); err != nil {
+					t.Fatal(err)
+				}
+				got, want := other.Load(), value
+				if math.IsNaN(want) {
+					if !math.IsNaN(got) {
+						t.Errorf("%s: GOT: %v; WANT: %v", buf, got, want)
+					}
+				} else if math.Float64bits(got) != math.Float64bits(want) {
+					t.Errorf("%s: GOT: %v; WANT: %v", buf, got, want)
+				}
+			})
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		for _, input := range []string{"", "one", "1e400", "1.5x"} {
+			eachImplementation(t, 7, func(t *testing.T, af AtomicFloat) {
+				if err := af.(textFloat).UnmarshalText([]byte(input)); err == nil {
+					t.Errorf("%q: GOT: nil; WANT: error", input)
+				}
+				if got, want := af.Load(), 7.0; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+		}
+	})
+}
+
+func TestSliceCSV(t *testing.T) {
+	values := []float64{1.5, -0.1, math.Inf(1), math.Inf(-1), math.NaN(), math.Copysign(0, -1), math.MaxFloat64}
+	s := NewSlice(len(values))
+	for i, v := range values {
+		s.Store(i, v)
+	}
+	b, err := s.MarshalCSV()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "1.5,-0.1,+Inf,-Inf,NaN,-0,1.7976931348623157e+308\n"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+
+	other := NewSlice(len(values))
+	if err := other.UnmarshalCSV(b); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range values {
+		if got := other.Load(i); math.Float64bits(got) != math.Float64bits(want) && !(math.IsNaN(got) && math.IsNaN(want)) {
+			t.Errorf("%d: GOT: %v; WANT: %v", i, got, want)
+		}
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		b, err := NewSlice(0).MarshalCSV()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := NewSlice(0).UnmarshalCSV(b); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		for _, input := range []string{"1,2", "1,2,3,4", "1,two,3", "1,2,3\n4,5,6\n", "1,\"2"} {
+			s := NewSlice(3)
+			s.Store(0, 7)
+			if err := s.UnmarshalCSV([]byte(input)); err == nil {
+				t.Errorf("%q: GOT: nil; WANT: error", input)
+			}
+			if got, want := s.Load(0), 7.0; got != want {
+				t.Errorf("%q: GOT: %v; WANT: %v", input, got, want)
+			}
+		}
+	})
+}
+
+func TestBinary(t *testing.T) {
+	type binaryFloat interface {
+		AtomicFloat
+		Bits() uint64
+		encoding.BinaryMarshaler
+		encoding.BinaryUnmarshaler
+	}
+
+	bitPatterns := []uint64{
+		0,
+		1 << 63, // -0
+		1,       // smallest subnormal
+		math.Float64bits(math.Inf(-1)),
+		0x7ff8000000000001, // quiet NaN with payload
+		0x3fb999999999999a, // 0.1
+	}
+
+	t.Run("round-trip", func(t *testing.T) {
+		for _, bits := range bitPatterns {
+			eachImplementation(t, math.Float64frombits(bits), func(t *testing.T, af AtomicFloat) {
+				buf, err := af.(binaryFloat).MarshalBinary()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, want := len(buf), 8; got != want {
+					t.Fatalf("GOT: %v; WANT: %v", got, want)
+				}
+				other := NewAtomicFloatCAS(42)
+				if err := other.UnmarshalBinary(buf); err != nil {
+					t.Fatal(err)
+				}
+				if got, want := other.Bits(), bits; got != want {
+					t.Errorf("GOT: %x; WANT: %x", got, want)
+				}
+			})
+		}
+	})
+
+	t.Run("gob", func(t *testing.T) {
+		type record struct {
+			Name    string
+			CAS     *atomicFloatCAS
+			Mutex   *atomicFloatMutex
+			Counter int
+		}
+		for _, bits := range bitPatterns {
+			f := math.Float64frombits(bits)
+			in := record{Name: "gauge", CAS: NewAtomicFloatCASFromBits(bits), Mutex: NewAtomicFloatMutex(f), Counter: 3}
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+				t.Fatal(err)
+			}
+			var out record
+			if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+				t.Fatal(err)
+			}
+			if out.Name != in.Name || out.Counter != in.Counter {
+				t.Errorf("GOT: %v; WANT: %v", out, in)
+			}
+			if got, want := out.CAS.Bits(), bits; got != want {
+				t.Errorf("GOT: %x; WANT: %x", got, want)
+			}
+			if got, want := out.Mutex.Bits(), bits; got != want {
+				t.Errorf("GOT: %x; WANT: %x", got, want)
+			}
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		for _, input := range [][]byte{nil, make([]byte, 7), make([]byte, 9)} {
+			eachImplementation(t, 7, func(t *testing.T, af AtomicFloat) {
+				if err := af.(binaryFloat).UnmarshalBinary(input); err == nil {
+					t.Errorf("%d bytes: GOT: nil; WANT: error", len(input))
+				}
+				if got, want := af.Load(), 7.0; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+		}
+	})
+}
+
+func TestSQL(t *testing.T) {
+	type sqlFloat interface {
+		AtomicFloat
+		sql.Scanner
+		driver.Valuer
+	}
+
+	t.Run("scan", func(t *testing.T) {
+		cases := []struct {
+			src  any
+			want float64
+		}{
+			{float64(-2.5), -2.5},
+			{int64(42), 42},
+			{[]byte("3.25"), 3.25},
+			{"0.1", 0.1},  // numeric string column
+			{"1e3", 1000}, // exponent notation
+			{nil, 7},      // NULL leaves the value unchanged
+		}
+		for _, c := range cases {
+			eachImplementation(t, 7, func(t *testing.T, af AtomicFloat) {
+				if err := af.(sqlFloat).Scan(c.src); err != nil {
+					t.Fatal(err)
+				}
+				if got, want := af.Load(), c.want; got != want {
+					t.Errorf("%#v: GOT: %v; WANT: %v", c.src, got, want)
+				}
+			})
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		for _, src := range []any{"abc", []byte{}, true, int32(1)} {
+			eachImplementation(t, 7, func(t *testing.T, af AtomicFloat) {
+				if err := af.(sqlFloat).Scan(src); err == nil {
+					t.Errorf("%#v: GOT: nil; WANT: error", src)
+				}
+				if got, want := af.Load(), 7.0; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+		}
+	})
+
+	t.Run("value", func(t *testing.T) {
+		eachImplementation(t, 1.5, func(t *testing.T, af AtomicFloat) {
+			v, err := af.(sqlFloat).Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !driver.IsValue(v) {
+				t.Errorf("GOT: %T; WANT: valid driver.Value", v)
+			}
+			if got, want := v, driver.Value(1.5); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	})
+}
+
+// expvarRuns counts the runs of TestExpvarFloat, so that each run publishes
+// under a new name, as expvar forbids reusing a name.
+var expvarRuns int
+
+func TestExpvarFloat(t *testing.T) {
+	expvarRuns++
+	name := "TestExpvarFloat" + strconv.Itoa(expvarRuns)
+	v := PublishFloat(name, 0.5)
+	parallel(100, func(int) {
+		for i := 0; i < 100; i++ {
+			v.Add(1)
+		}
+	})
+	if got, want := expvar.Get(name).String(), "10000.5"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	v.Set(math.NaN())
+	if got, want := v.String(), "null"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if !json.Valid([]byte(v.String())) {
+		t.Errorf("GOT: %v; WANT: valid JSON", v.String())
+	}
+}
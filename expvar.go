@@ -0,0 +1,43 @@
+package atomic
+
+import "expvar"
+
+// ExpvarFloat is an expvar.Var holding an atomic float, so that it may be
+// updated concurrently and published under /debug/vars.
+type ExpvarFloat struct {
+	af atomicFloatCAS
+}
+
+var _ expvar.Var = (*ExpvarFloat)(nil)
+
+// PublishFloat returns a new ExpvarFloat initialized to initial, published
+// with expvar under name. Like expvar.Publish, it panics when name is already
+// in use.
+func PublishFloat(name string, initial float64) *ExpvarFloat {
+	v := new(ExpvarFloat)
+	v.Set(initial)
+	expvar.Publish(name, v)
+	return v
+}
+
+// Add atomically adds delta to the value and returns the new value.
+func (v *ExpvarFloat) Add(delta float64) float64 {
+	return v.af.Add(delta)
+}
+
+// Set atomically stores value.
+func (v *ExpvarFloat) Set(value float64) {
+	v.af.Store(value)
+}
+
+// Value atomically loads the current value.
+func (v *ExpvarFloat) Value() float64 {
+	return v.af.Load()
+}
+
+// String implements expvar.Var, returning the current value as a JSON number.
+// Because JSON cannot represent NaN or ±Inf, those values are returned as
+// null.
+func (v *ExpvarFloat) String() string {
+	return string(appendJSONFloat(nil, v.af.Load()))
+}
@@ -0,0 +1,65 @@
+package atomic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+)
+
+// ErrOverflow is returned by FixedPoint.Add when the value, or the resulting
+// sum, cannot be represented at the fixed-point scale.
+var ErrOverflow = errors.New("atomic: fixed-point overflow")
+
+// FixedPoint is an exact concurrency-safe accumulator of decimal amounts, such
+// as currency, with a float-like API. Values are rounded to a fixed number of
+// decimal places and stored as a scaled int64, so sums of amounts with no more
+// than that many places are exact, unlike sums of float64.
+type FixedPoint struct {
+	units  atomic.Int64 // value multiplied by factor
+	factor float64      // 10 raised to the scale
+}
+
+// NewFixedPoint returns a new FixedPoint, initialized to 0, that stores values
+// rounded to scale decimal places. It panics unless scale is between 0 and 18,
+// inclusive, as larger scales leave no integer digits in an int64.
+func NewFixedPoint(scale int) *FixedPoint {
+	if scale < 0 || scale > 18 {
+		panic(fmt.Sprintf("atomic: NewFixedPoint scale must be between 0 and 18: %d", scale))
+	}
+	return &FixedPoint{factor: math.Pow10(scale)}
+}
+
+// Add atomically adds v, rounded half to even to the nearest multiple of the
+// scale, and returns the new value. When v is NaN or ±Inf, or when either the
+// scaled v or the new sum would overflow an int64, the stored value is left
+// untouched and Add returns it along with ErrOverflow, rather than saturating
+// or wrapping around.
+func (f *FixedPoint) Add(v float64) (float64, error) {
+	scaled := math.RoundToEven(v * f.factor)
+	if !(scaled >= -0x1p63 && scaled < 0x1p63) {
+		return f.Load(), fmt.Errorf("%w: %v", ErrOverflow, v)
+	}
+	delta := int64(scaled)
+	for {
+		old := f.units.Load()
+		new := old + delta
+		if (delta > 0 && new < old) || (delta < 0 && new > old) {
+			return float64(old) / f.factor, fmt.Errorf("%w: %v", ErrOverflow, v)
+		}
+		if f.units.CompareAndSwap(old, new) {
+			return float64(new) / f.factor, nil
+		}
+	}
+}
+
+// Load atomically loads the current value, converted to the nearest float64.
+func (f *FixedPoint) Load() float64 {
+	return float64(f.units.Load()) / f.factor
+}
+
+// Units atomically loads the current value as the exact number of units of the
+// scale, such as cents when the scale is 2.
+func (f *FixedPoint) Units() int64 {
+	return f.units.Load()
+}
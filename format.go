@@ -0,0 +1,177 @@
+package atomic
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// textFormat is the strconv format verb and precision with which an atomic
+// float's String and MarshalText methods format its value, packed into a
+// single word so that it may be changed while the value is being formatted.
+// The zero value formats in the shortest representation that parses back to
+// the same value, as if by verb 'g' and precision -1.
+type textFormat struct {
+	bits atomic.Uint32 // verb<<24 | (prec+1), or 0 for the default
+}
+
+// maxTextPrecision is the largest precision textFormat can represent.
+const maxTextPrecision = 1<<24 - 2
+
+// set changes the verb and precision, panicking when strconv.FormatFloat does
+// not accept verb, or when prec is less than -1 or absurdly large.
+func (f *textFormat) set(verb byte, prec int) {
+	if verb == 0 || !strings.ContainsRune("beEfgGxX", rune(verb)) {
+		panic(fmt.Sprintf("atomic: SetFormat verb is not a floating point format: %q", verb))
+	}
+	if prec < -1 || prec > maxTextPrecision {
+		panic(fmt.Sprintf("atomic: SetFormat precision out of range: %d", prec))
+	}
+	f.bits.Store(uint32(verb)<<24 | uint32(prec+1))
+}
+
+// get returns the verb and precision.
+func (f *textFormat) get() (verb byte, prec int) {
+	bits := f.bits.Load()
+	if bits == 0 {
+		return 'g', -1
+	}
+	return byte(bits >> 24), int(bits&(1<<24-1)) - 1
+}
+
+// appendFloat appends v formatted with the verb and precision to b.
+func (f *textFormat) appendFloat(b []byte, v float64) []byte {
+	verb, prec := f.get()
+	return strconv.AppendFloat(b, v, verb, prec, 64)
+}
+
+// formatFloat writes f to s as fmt would format a float64 for verb, honoring
+// the width, precision, and the '+', '-', ' ', '0', and '#' flags. Verbs that
+// are not meaningful for floating point values print f as if by the 'v' verb.
+// As with fmt, %+v and %#v print f just as %v does.
+func formatFloat(s fmt.State, verb rune, f float64) {
+	prec, ok := s.Precision()
+	if !ok {
+		prec = -1
+	}
+	plus, sharp := s.Flag('+'), s.Flag('#')
+
+	switch verb {
+	case 'b', 'e', 'E', 'f', 'g', 'G', 'x', 'X':
+	case 'F':
+		verb = 'f'
+	default:
+		verb = 'g'
+		plus, sharp = false, false
+	}
+	if !ok && (verb == 'e' || verb == 'E' || verb == 'f') {
+		prec = 6
+	}
+
+	// Format with a leading sign, so that it may be repositioned before any
+	// zero padding.
+	num := strconv.AppendFloat([]byte{'+'}, f, byte(verb), prec, 64)
+	if num[1] == '-' || num[1] == '+' {
+		num = num[1:]
+	}
+	if s.Flag(' ') && num[0] == '+' && !plus {
+		num[0] = ' '
+	}
+	if sharp && verb != 'b' && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		num = appendSharp(num, byte(verb), prec)
+	}
+
+	width, _ := s.Width()
+	zero := s.Flag('0') && !s.Flag('-') && !math.IsInf(f, 0) && !math.IsNaN(f)
+
+	switch {
+	case math.IsNaN(f) && !plus && !s.Flag(' '):
+		num = num[1:]
+	case math.IsInf(f, 0):
+	case num[0] == '+' && !plus:
+		num = num[1:]
+	case zero && width > len(num):
+		// Write the sign ahead of the zero padding.
+		s.Write(num[:1])
+		writePadding(s, '0', width-len(num))
+		s.Write(num[1:])
+		return
+	}
+
+	switch {
+	case width <= len(num):
+		s.Write(num)
+	case s.Flag('-'):
+		s.Write(num)
+		writePadding(s, ' ', width-len(num))
+	case zero:
+		writePadding(s, '0', width-len(num))
+		s.Write(num)
+	default:
+		writePadding(s, ' ', width-len(num))
+		s.Write(num)
+	}
+}
+
+// appendSharp returns num, a formatted number preceded by its sign, with a
+// decimal point and any trailing zeros that the '#' flag requires restored, as
+// fmt does for verb and prec.
+func appendSharp(num []byte, verb byte, prec int) []byte {
+	digits := 0
+	switch verb {
+	case 'g', 'G', 'x':
+		digits = prec
+		if digits == -1 {
+			digits = 6
+		}
+	}
+
+	// Set aside the exponent, of the form "e+123" or "p-1023".
+	var tail []byte
+	hasDecimalPoint := false
+	sawNonzeroDigit := false
+	for i := 1; i < len(num); i++ {
+		switch num[i] {
+		case '.':
+			hasDecimalPoint = true
+		case 'p', 'P':
+			tail = append(tail, num[i:]...)
+			num = num[:i]
+		case 'e', 'E':
+			if verb != 'x' && verb != 'X' {
+				tail = append(tail, num[i:]...)
+				num = num[:i]
+				break
+			}
+			fallthrough
+		default:
+			if num[i] != '0' {
+				sawNonzeroDigit = true
+			}
+			// Count significant digits after the first non-zero digit.
+			if sawNonzeroDigit {
+				digits--
+			}
+		}
+	}
+	if !hasDecimalPoint {
+		// A lone leading zero is itself a significant digit.
+		if len(num) == 2 && num[1] == '0' {
+			digits--
+		}
+		num = append(num, '.')
+	}
+	for ; digits > 0; digits-- {
+		num = append(num, '0')
+	}
+	return append(num, tail...)
+}
+
+// writePadding writes n copies of b to s.
+func writePadding(s fmt.State, b byte, n int) {
+	for i := 0; i < n; i++ {
+		s.Write([]byte{b})
+	}
+}
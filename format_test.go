@@ -0,0 +1,217 @@
+package atomic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	cases := []struct {
+		value float64
+		want  string
+	}{
+		{0, "0"},
+		{math.Copysign(0, -1), "-0"},
+		{1.5, "1.5"},
+		{-0.1, "-0.1"},
+		{1e21, "1e+21"},
+		{math.Inf(1), "+Inf"},
+		{math.Inf(-1), "-Inf"},
+		{math.NaN(), "NaN"},
+	}
+	for _, c := range cases {
+		t.Run(c.want, func(t *testing.T) {
+			eachImplementation(t, c.value, func(t *testing.T, af AtomicFloat) {
+				if got, want := af.(fmt.Stringer).String(), c.want; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+				if got, want := fmt.Sprintf("%v", af), c.want; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+				if got, want := fmt.Sprintf("%s", af), c.want; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			})
+		})
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	type formatFloat interface {
+		AtomicFloat
+		SetFormat(verb byte, prec int)
+		MarshalText() ([]byte, error)
+	}
+
+	eachImplementation(t, 3.14159, func(t *testing.T, af AtomicFloat) {
+		a := af.(formatFloat)
+		a.SetFormat('f', 2)
+		if got, want := af.(fmt.Stringer).String(), "3.14"; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		b, err := a.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(b), "3.14"; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		a.SetFormat('g', -1)
+		if got, want := af.(fmt.Stringer).String(), "3.14159"; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+
+		// Changing the format while formatting yields one of the formats.
+		parallel(2, func(i int) {
+			for j := 0; j < 1000; j++ {
+				if i == 0 {
+					a.SetFormat('e', j%2)
+					continue
+				}
+				if got := af.(fmt.Stringer).String(); got != "3e+00" && got != "3.1e+00" && got != "3.14159" {
+					t.Errorf("GOT: %v; WANT: 3e+00 or 3.1e+00", got)
+					return
+				}
+			}
+		})
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, c := range []struct {
+			verb byte
+			prec int
+		}{{'v', -1}, {0, 2}, {'f', -2}} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%q, %d: GOT: no panic; WANT: panic", c.verb, c.prec)
+					}
+				}()
+				NewAtomicFloatCAS(0).SetFormat(c.verb, c.prec)
+			}()
+		}
+	})
+}
+
+func TestFormat(t *testing.T) {
+	values := []float64{0, math.Copysign(0, -1), 3.14159, -2.5e-7, 12345678, math.Inf(1), math.Inf(-1), math.NaN()}
+	formats := []string{
+		"%v", "%f", "%e", "%E", "%g", "%G", "%F", "%b", "%x", "%X",
+		"%8.3f", "%-8.3f|", "%08.3f", "%+.2f", "% .2f", "%+08.2e", "% 010g",
+		"%.3g", "%12v", "%-12e|", "%.0f", "%3.1f",
+		"%+v", "%#v", "% v", "%+12v", "%#g", "%#G", "%#.3g", "%#e", "%#.0f",
+		"%#.0e", "%#x", "%#.2X", "%#b", "%#010g", "%#+g",
+	}
+	for _, value := range values {
+		for _, format := range formats {
+			want := fmt.Sprintf(format, value)
+			eachImplementation(t, value, func(t *testing.T, af AtomicFloat) {
+				if got := fmt.Sprintf(format, af); got != want {
+					t.Errorf("%q: GOT: %q; WANT: %q", format, got, want)
+				}
+			})
+		}
+	}
+
+	t.Run("unknown-verb", func(t *testing.T) {
+		eachImplementation(t, 1.5, func(t *testing.T, af AtomicFloat) {
+			if got, want := fmt.Sprintf("%d", af), "1.5"; got != want {
+				t.Errorf("GOT: %q; WANT: %q", got, want)
+			}
+		})
+	})
+}
+
+func TestFmtScanner(t *testing.T) {
+	cases := []struct {
+		input, format string
+		want          float64
+	}{
+		{"3.25", "%f", 3.25},
+		{"-1e-3", "%g", -0.001},
+		{"1.5E+2", "%e", 150},
+		{"  42  ", "%g", 42},
+		{"\t0.5\n", "%v", 0.5},
+		{"total=7.75;", "total=%g;", 7.75},
+		{"Inf", "%g", math.Inf(1)},
+		{"0x1p-2", "%x", 0.25},
+	}
+	for _, c := range cases {
+		eachImplementation(t, 99, func(t *testing.T, af AtomicFloat) {
+			n, err := fmt.Sscanf(c.input, c.format, FmtScanner(af))
+			if err != nil {
+				t.Fatalf("%q: %v", c.input, err)
+			}
+			if got, want := n, 1; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+			if got, want := af.Load(), c.want; got != want {
+				t.Errorf("%q: GOT: %v; WANT: %v", c.input, got, want)
+			}
+		})
+	}
+
+	t.Run("multiple", func(t *testing.T) {
+		a, b := NewAtomicFloatCAS(0), NewAtomicFloatMutex(0)
+		if _, err := fmt.Sscan(" 1.5\n -2.5 ", FmtScanner(a), FmtScanner(b)); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := a.Load(), 1.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := b.Load(), -2.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		for _, c := range []struct{ input, format string }{{"abc", "%g"}, {"1.5", "%d"}, {"", "%g"}} {
+			af := NewAtomicFloatCAS(7)
+			if _, err := fmt.Sscanf(c.input, c.format, FmtScanner(af)); err == nil {
+				t.Errorf("%q: GOT: nil; WANT: error", c.input)
+			}
+			if got, want := af.Load(), 7.0; got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		}
+	})
+}
+
+// recordingHandler is a slog.Handler that records the attributes of every
+// record it handles.
+type recordingHandler struct {
+	attrs []slog.Attr
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	r.Attrs(func(a slog.Attr) bool {
+		h.attrs = append(h.attrs, a)
+		return true
+	})
+	return nil
+}
+
+func TestLogValue(t *testing.T) {
+	eachImplementation(t, 2.5, func(t *testing.T, af AtomicFloat) {
+		h := new(recordingHandler)
+		slog.New(h).Info("m", "counter", af)
+		if got, want := len(h.attrs), 1; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		v := h.attrs[0].Value.Resolve()
+		if got, want := v.Kind(), slog.KindFloat64; got != want {
+			t.Fatalf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := v.Float64(), 2.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
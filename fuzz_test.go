@@ -0,0 +1,77 @@
+package atomic
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// FuzzAtomicFloat applies a fuzzed sequence of operations to each core
+// implementation and to a plain float64, and requires that they agree bit for
+// bit. Each operation is encoded in ops as a byte selecting Store, Add, or
+// Swap, followed by the 8 byte IEEE 754 binary representation of its operand.
+// Values are compared by their bit patterns, so the sign of zero and NaN
+// payloads must be preserved, except that when an addition produces NaN, any
+// NaN is accepted: adding two NaNs yields the payload of one of them, and which
+// one depends on the order in which the compiler places the operands.
+func FuzzAtomicFloat(f *testing.F) {
+	specials := []float64{
+		0,
+		math.Copysign(0, -1),
+		math.Inf(1),
+		math.Inf(-1),
+		math.NaN(),
+		math.SmallestNonzeroFloat64,
+		-math.SmallestNonzeroFloat64,
+		math.MaxFloat64,
+	}
+	var ops []byte
+	for i, v := range specials {
+		ops = append(ops, byte(i))
+		ops = binary.BigEndian.AppendUint64(ops, math.Float64bits(v))
+	}
+	for _, v := range specials {
+		f.Add(math.Float64bits(v), ops)
+	}
+
+	f.Fuzz(func(t *testing.T, initial uint64, ops []byte) {
+		type bitsFloat interface {
+			AtomicFloat
+			Bits() uint64
+		}
+		afs := map[string]bitsFloat{
+			"cas":  NewAtomicFloatCASFromBits(initial),
+			"cas2": NewAtomicFloatCAS2(math.Float64frombits(initial)),
+			"lock": NewAtomicFloatMutex(math.Float64frombits(initial)),
+		}
+
+		for name, af := range afs {
+			want := math.Float64frombits(initial)
+			for ops := ops; len(ops) >= 9; ops = ops[9:] {
+				op, v := ops[0]%3, math.Float64frombits(binary.BigEndian.Uint64(ops[1:9]))
+				var got, result float64
+				switch op {
+				case 0:
+					af.Store(v)
+					want = v
+				case 1:
+					got = af.Add(v)
+					want += v
+					if math.IsNaN(got) && math.IsNaN(want) {
+						want = got // either payload is correct
+					}
+					result = want
+				case 2:
+					got = af.Swap(v)
+					result, want = want, v
+				}
+				if math.Float64bits(got) != math.Float64bits(result) {
+					t.Fatalf("%s: op %d(%v): GOT: %v (%#x); WANT: %v (%#x)", name, op, v, got, math.Float64bits(got), result, math.Float64bits(result))
+				}
+				if got, want := af.Bits(), math.Float64bits(want); got != want {
+					t.Fatalf("%s: op %d(%v): GOT: %#x; WANT: %#x", name, op, v, got, want)
+				}
+			}
+		}
+	})
+}
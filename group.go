@@ -0,0 +1,72 @@
+package atomic
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Group is a set of atomic floats that may be mutated together and read as a
+// consistent snapshot, such as a sum and a count whose ratio must not tear.
+// Writes through the group are serialized by a mutex, so that waiting writers
+// sleep rather than spin, and bracketed by a seqlock, so that Snapshot never
+// blocks writers and retries whenever a group write overlapped its reads.
+//
+// Members remain ordinary atomic floats and may still be mutated individually
+// outside the group, but such writes are not bracketed by the seqlock, so
+// Snapshot only guarantees a torn-free view of the group when all writes go
+// through the group.
+type Group struct {
+	sl      seqlock
+	l       sync.Mutex // serializes group writers
+	members []AtomicFloat
+}
+
+// NewGroup returns a new Group managing members, in order.
+func NewGroup(members ...AtomicFloat) *Group {
+	return &Group{members: append([]AtomicFloat(nil), members...)}
+}
+
+// Len returns the number of members in the group.
+func (g *Group) Len() int { return len(g.members) }
+
+// write invokes fn for each member while holding the seqlock. It panics unless
+// values has one element for each member.
+func (g *Group) write(method string, values []float64, fn func(af AtomicFloat, v float64)) {
+	if len(values) != len(g.members) {
+		panic(fmt.Sprintf("atomic: Group.%s requires %d values: %d", method, len(g.members), len(values)))
+	}
+	g.l.Lock()
+	g.sl.lock() // never waits, as the mutex excludes other writers
+	for i, af := range g.members {
+		fn(af, values[i])
+	}
+	g.sl.unlock()
+	g.l.Unlock()
+}
+
+// AddAll adds deltas to the respective members as a single group write. It
+// panics unless there is one delta for each member.
+func (g *Group) AddAll(deltas ...float64) {
+	g.write("AddAll", deltas, func(af AtomicFloat, delta float64) { af.Add(delta) })
+}
+
+// StoreAll stores values into the respective members as a single group write.
+// It panics unless there is one value for each member.
+func (g *Group) StoreAll(values ...float64) {
+	g.write("StoreAll", values, func(af AtomicFloat, v float64) { af.Store(v) })
+}
+
+// Snapshot returns the values of all members, in order, as observed between
+// group writes.
+func (g *Group) Snapshot() []float64 {
+	values := make([]float64, len(g.members))
+	for {
+		seq := g.sl.beginRead()
+		for i, af := range g.members {
+			values[i] = af.Load()
+		}
+		if g.sl.validRead(seq) {
+			return values
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	t.Run("store", func(t *testing.T) {
+		g := NewGroup(NewAtomicFloatCAS(0), NewAtomicFloatMutex(0))
+		g.StoreAll(1.5, -2)
+		got := g.Snapshot()
+		if len(got) != 2 || got[0] != 1.5 || got[1] != -2 {
+			t.Errorf("GOT: %v; WANT: %v", got, []float64{1.5, -2})
+		}
+	})
+
+	t.Run("length", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("GOT: no panic; WANT: panic")
+			}
+		}()
+		NewGroup(NewAtomicFloatCAS(0)).AddAll(1, 2)
+	})
+
+	t.Run("consistent", func(t *testing.T) {
+		// Every group write preserves sum == 3*count, so every snapshot must
+		// observe that ratio exactly.
+		sum, count := NewAtomicFloatCAS(0), NewAtomicFloatCAS2(0)
+		g := NewGroup(sum, count)
+		parallel(8, func(i int) {
+			for j := 0; j < 1000; j++ {
+				if i%2 == 0 {
+					g.AddAll(3, 1)
+					continue
+				}
+				if s := g.Snapshot(); s[0] != 3*s[1] {
+					t.Errorf("GOT: %v; WANT: sum == 3*count", s)
+					return
+				}
+			}
+		})
+		if got, want := count.Load(), 4000.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := sum.Load(), 12000.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestWatcher(t *testing.T) {
+	af := NewAtomicFloatCAS(1)
+	w1, w2 := NewWatcher(af), NewWatcher(af)
+
+	check := func(w *Watcher, want float64, wantChanged bool) {
+		t.Helper()
+		got, changed := w.LoadIfChanged()
+		if math.Float64bits(got) != math.Float64bits(want) && !(math.IsNaN(got) && math.IsNaN(want)) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if changed != wantChanged {
+			t.Errorf("%v: GOT: %v; WANT: %v", want, changed, wantChanged)
+		}
+	}
+
+	check(w1, 1, true)
+	check(w1, 1, false)
+	af.Add(1)
+	check(w1, 2, true)
+	check(w2, 2, true) // each watcher tracks its own last-seen value
+	check(w2, 2, false)
+	af.Store(math.NaN())
+	check(w1, math.NaN(), true)
+	check(w1, math.NaN(), false)
+	af.Store(0)
+	check(w1, 0, true)
+	af.Store(math.Copysign(0, -1))
+	check(w1, math.Copysign(0, -1), true)
+	af.Add(1)
+	af.Add(-1)
+	check(w1, 0, true)
+	check(w2, 0, true)
+}
+
+func TestVersionedFloat(t *testing.T) {
+	v := NewVersionedFloat(1.5)
+	if got, gen := v.Load(); got != 1.5 || gen != 0 {
+		t.Errorf("GOT: %v at %v; WANT: 1.5 at 0", got, gen)
+	}
+	if got, want := v.Store(2), uint64(1); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if old, gen := v.Swap(3); old != 2 || gen != 2 {
+		t.Errorf("GOT: %v at %v; WANT: 2 at 2", old, gen)
+	}
+	if got, gen := v.Add(1); got != 4 || gen != 3 {
+		t.Errorf("GOT: %v at %v; WANT: 4 at 3", got, gen)
+	}
+
+	t.Run("stale", func(t *testing.T) {
+		v := NewVersionedFloat(1)
+		_, gen := v.Load()
+		v.Add(1)
+		v.Add(-1) // restores the value, but not the generation
+		if v.CompareVersionAndSwap(gen, 10) {
+			t.Errorf("GOT: true; WANT: false")
+		}
+		f, gen := v.Load()
+		if !v.CompareVersionAndSwap(gen, 10) {
+			t.Errorf("GOT: false; WANT: true")
+		}
+		if got, want := f, 1.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, gen := v.Load(); got != 10 || gen != 3 {
+			t.Errorf("GOT: %v at %v; WANT: 10 at 3", got, gen)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// The generation strictly increases as observed by each writer and
+		// reader, and only one of any writers expecting the same
+		// generation may succeed.
+		const writers, writes = 8, 1000
+		v := NewVersionedFloat(0)
+		var swaps atomic.Int64
+		parallel(writers+1, func(i int) {
+			var last uint64
+			for j := 0; j < writes; j++ {
+				var f float64
+				var gen uint64
+				switch {
+				case i == writers:
+					f, gen = v.Load()
+					if gen < last || f != float64(int64(f)) {
+						t.Errorf("GOT: %v at %v after %v; WANT: whole number, generation not decreasing", f, gen, last)
+						return
+					}
+				case i%2 == 0:
+					_, gen = v.Add(1)
+					if gen <= last {
+						t.Errorf("GOT: %v after %v; WANT: increasing generation", gen, last)
+						return
+					}
+				default:
+					f, gen = v.Load()
+					if v.CompareVersionAndSwap(gen, f+1) {
+						swaps.Add(1)
+					}
+				}
+				last = gen
+			}
+		})
+		f, gen := v.Load()
+		if got, want := f, float64(writers/2*writes+swaps.Load()); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := gen, uint64(writers/2*writes+swaps.Load()); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
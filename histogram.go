@@ -0,0 +1,79 @@
+package atomic
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Histogram is a lock-free histogram of observations, counted in buckets with
+// fixed upper bounds. Each bucket is an independent atomic counter on its own
+// cache line, so concurrent observers of values in different buckets do not
+// contend with one another.
+type Histogram struct {
+	bounds  []float64
+	buckets []PaddedAtomicFloatCAS // one per bound, plus one for overflow
+	count   atomicFloatCAS
+	sum     atomicFloatCAS
+}
+
+// HistogramSnapshot is a point-in-time copy of the state of a Histogram.
+type HistogramSnapshot struct {
+	// Bounds are the upper bounds of the buckets, in increasing order.
+	Bounds []float64
+
+	// Counts are the number of observations in each bucket. Counts[i] is
+	// the number of observations greater than Bounds[i-1] and less than or
+	// equal to Bounds[i]. The final element, Counts[len(Bounds)], is the
+	// overflow bucket, holding observations greater than every bound, and
+	// NaN.
+	Counts []float64
+
+	// Count is the total number of observations.
+	Count float64
+
+	// Sum is the sum of the observations.
+	Sum float64
+}
+
+// NewHistogram returns a new Histogram with buckets having the specified upper
+// bounds, plus an overflow bucket. It panics unless bounds are in strictly
+// increasing order.
+func NewHistogram(bounds ...float64) *Histogram {
+	for i := 1; i < len(bounds); i++ {
+		if !(bounds[i-1] < bounds[i]) {
+			panic(fmt.Sprintf("atomic: NewHistogram bounds must be strictly increasing: %v", bounds))
+		}
+	}
+	return &Histogram{
+		bounds:  append([]float64(nil), bounds...),
+		buckets: make([]PaddedAtomicFloatCAS, len(bounds)+1),
+	}
+}
+
+// Observe atomically counts x in the bucket with the least upper bound greater
+// than or equal to x, or in the overflow bucket when there is none.
+func (h *Histogram) Observe(x float64) {
+	// Count the observation in the total before its bucket, and load them in
+	// the opposite order in Snapshot, so that the total is never less than
+	// the sum of the buckets.
+	h.count.Inc()
+	h.sum.Add(x)
+	h.buckets[sort.SearchFloat64s(h.bounds, x)].Inc()
+}
+
+// Snapshot returns a copy of the current state of the histogram. Each bucket
+// and total is loaded atomically, but not all together, so when observations
+// are concurrent with Snapshot, Count may exceed the sum of Counts by the
+// number of observations in progress.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{
+		Bounds: append([]float64(nil), h.bounds...),
+		Counts: make([]float64, len(h.buckets)),
+	}
+	for i := range h.buckets {
+		s.Counts[i] = h.buckets[i].Load()
+	}
+	s.Count = h.count.Load()
+	s.Sum = h.sum.Load()
+	return s
+}
@@ -0,0 +1,83 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// atomicFloatInstrumentedCAS is a compare-and-swap atomic float that counts the
+// failed compare-and-swap operations of its retry loops, to measure how
+// contended it is. The count is only updated on failure, so an uncontended
+// update costs no more than it does for atomicFloatCAS.
+type atomicFloatInstrumentedCAS struct {
+	u64      atomic.Uint64
+	failures atomic.Uint64
+}
+
+func NewAtomicFloatInstrumentedCAS(initial float64) *atomicFloatInstrumentedCAS {
+	a := new(atomicFloatInstrumentedCAS)
+	a.u64.Store(math.Float64bits(initial))
+	return a
+}
+
+// Add attempts to add delta to the value stored in the atomic float and return
+// the new value.
+func (a *atomicFloatInstrumentedCAS) Add(delta float64) float64 {
+	var newValue float64
+	var oldBits, newBits uint64
+	for {
+		oldBits = a.u64.Load()
+		newValue = math.Float64frombits(oldBits) + delta
+		newBits = math.Float64bits(newValue)
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			return newValue
+		}
+		a.failures.Add(1)
+	}
+}
+
+// Update atomically replaces the value stored in the atomic float with the
+// result of calling fn with the current value, and returns the new value. fn
+// is invoked inside the retry loop, and may be called more than once
+// under contention, so it must be free of side effects.
+func (a *atomicFloatInstrumentedCAS) Update(fn func(old float64) (new float64)) float64 {
+	var newValue float64
+	var oldBits, newBits uint64
+	for {
+		oldBits = a.u64.Load()
+		newValue = fn(math.Float64frombits(oldBits))
+		newBits = math.Float64bits(newValue)
+		if a.u64.CompareAndSwap(oldBits, newBits) {
+			return newValue
+		}
+		a.failures.Add(1)
+	}
+}
+
+// Load atomically loads the current atomic float value.
+func (a *atomicFloatInstrumentedCAS) Load() float64 {
+	return math.Float64frombits(a.u64.Load())
+}
+
+// Store atomically stores new into the atomic float.
+func (a *atomicFloatInstrumentedCAS) Store(new float64) {
+	a.u64.Store(math.Float64bits(new))
+}
+
+// Swap atomically stores new and returns the previous value.
+func (a *atomicFloatInstrumentedCAS) Swap(new float64) float64 {
+	return math.Float64frombits(a.u64.Swap(math.Float64bits(new)))
+}
+
+// ContentionCount atomically loads the total number of failed compare-and-swap
+// operations since the atomic float was created or ResetContention was last
+// called.
+func (a *atomicFloatInstrumentedCAS) ContentionCount() uint64 {
+	return a.failures.Load()
+}
+
+// ResetContention atomically resets the count of failed compare-and-swap
+// operations to zero.
+func (a *atomicFloatInstrumentedCAS) ResetContention() {
+	a.failures.Store(0)
+}
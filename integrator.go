@@ -0,0 +1,70 @@
+package atomic
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// integratorState is an immutable snapshot of an Integrator's level, the area
+// accumulated until that level was set, and the time it was set.
+type integratorState struct {
+	level float64
+	area  float64
+	at    time.Time
+}
+
+// Integrator is a concurrency-safe accumulator of the area under a level that
+// changes over time, such as byte-seconds of buffered data. The level is held
+// constant between successive calls to Set, so the area is a step integral, in
+// units of the level multiplied by seconds. The level, the accumulated area,
+// and the time of the last change are swapped together through a pointer to a
+// freshly allocated snapshot, so every Set allocates.
+type Integrator struct {
+	p   atomic.Pointer[integratorState]
+	now func() time.Time
+}
+
+// NewIntegrator returns a new Integrator whose level is zero, reading the
+// current time by calling now, or time.Now when now is nil. Until the first
+// Set, the level is zero, so no area accumulates.
+func NewIntegrator(now func() time.Time) *Integrator {
+	i := &Integrator{now: clockOrDefault(now)}
+	i.p.Store(&integratorState{at: i.now()})
+	return i
+}
+
+// areaAt returns the area of s accumulated until now. Time that appears to run
+// backwards accumulates no area.
+func (s *integratorState) areaAt(now time.Time) float64 {
+	elapsed := now.Sub(s.at).Seconds()
+	if elapsed <= 0 {
+		return s.area
+	}
+	return s.area + s.level*elapsed
+}
+
+// Set atomically accumulates the area under the previous level until now, and
+// changes the level to v.
+func (i *Integrator) Set(v float64) {
+	for {
+		old := i.p.Load()
+		now := i.now()
+		if now.Before(old.at) {
+			now = old.at
+		}
+		if i.p.CompareAndSwap(old, &integratorState{level: v, area: old.areaAt(now), at: now}) {
+			return
+		}
+	}
+}
+
+// Level atomically loads the current level.
+func (i *Integrator) Level() float64 {
+	return i.p.Load().level
+}
+
+// Integral returns the area accumulated under the level from construction
+// until the current time.
+func (i *Integrator) Integral() float64 {
+	return i.p.Load().areaAt(i.now())
+}
@@ -0,0 +1,55 @@
+package atomic
+
+import "sync/atomic"
+
+// compensatedSum is an immutable snapshot of a running sum along with the
+// compensation for low-order bits lost from it.
+type compensatedSum struct {
+	sum float64 // running sum
+	c   float64 // compensation for low-order bits lost from sum
+}
+
+// atomicFloatKahanCAS is an atomic float accumulator that uses Kahan summation
+// to retain the low-order bits lost when adding values of small magnitude to a
+// running sum of large magnitude. The sum and its compensation are updated
+// together by swapping a pointer to a freshly allocated state, so every Add
+// allocates.
+type atomicFloatKahanCAS struct {
+	p atomic.Pointer[compensatedSum]
+}
+
+func NewAtomicFloatKahanCAS(initial float64) *atomicFloatKahanCAS {
+	a := new(atomicFloatKahanCAS)
+	a.p.Store(&compensatedSum{sum: initial})
+	return a
+}
+
+// Add attempts to add delta to the value stored in the atomic float and return
+// the new value.
+func (a *atomicFloatKahanCAS) Add(delta float64) float64 {
+	for {
+		old := a.p.Load()
+		y := delta - old.c
+		t := old.sum + y
+		if a.p.CompareAndSwap(old, &compensatedSum{sum: t, c: (t - old.sum) - y}) {
+			return t
+		}
+	}
+}
+
+// Load atomically loads the current atomic float value.
+func (a *atomicFloatKahanCAS) Load() float64 {
+	return a.p.Load().sum
+}
+
+// Store atomically stores new into the atomic float, discarding any
+// accumulated compensation.
+func (a *atomicFloatKahanCAS) Store(new float64) {
+	a.p.Store(&compensatedSum{sum: new})
+}
+
+// Swap atomically stores new and returns the previous value, discarding any
+// accumulated compensation.
+func (a *atomicFloatKahanCAS) Swap(new float64) float64 {
+	return a.p.Swap(&compensatedSum{sum: new}).sum
+}
@@ -0,0 +1,77 @@
+package atomic
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// LeakyBucket is a lock-free leaky bucket, such as for smoothing bursty input,
+// which holds a level of up to capacity that leaks continuously at rate per
+// second. Leaking and adding is a single compare-and-swap of a pointer to a
+// freshly allocated snapshot of the level and the time it last leaked, so
+// concurrent adders can never fill the bucket beyond its capacity, and every
+// Add allocates.
+type LeakyBucket struct {
+	p        atomic.Pointer[bucketState]
+	rate     float64 // leaked per second
+	capacity float64
+	now      func() time.Time
+}
+
+// NewLeakyBucket returns a new, empty LeakyBucket holding at most capacity,
+// which leaks at rate per second, reading the current time by calling now, or
+// time.Now when now is nil. It panics when rate is negative or capacity is not
+// positive.
+func NewLeakyBucket(rate, capacity float64, now func() time.Time) *LeakyBucket {
+	if !(rate >= 0) || math.IsInf(rate, 0) {
+		panic(fmt.Sprintf("atomic: NewLeakyBucket rate must be finite and not negative: %v", rate))
+	}
+	if !(capacity > 0) || math.IsInf(capacity, 0) {
+		panic(fmt.Sprintf("atomic: NewLeakyBucket capacity must be finite and positive: %v", capacity))
+	}
+	b := &LeakyBucket{rate: rate, capacity: capacity, now: clockOrDefault(now)}
+	b.p.Store(&bucketState{at: b.now()})
+	return b
+}
+
+// leaked returns the level of s after leaking from when it last leaked until
+// now, down to zero. Time that appears to run backwards leaks nothing.
+func (b *LeakyBucket) leaked(s *bucketState, now time.Time) float64 {
+	elapsed := now.Sub(s.at).Seconds()
+	if elapsed <= 0 {
+		return s.tokens
+	}
+	return math.Max(s.tokens-elapsed*b.rate, 0)
+}
+
+// Add atomically leaks the bucket, then pours amount into it, and returns the
+// overflow that did not fit, which is zero unless the bucket is full. A
+// negative amount drains the bucket, down to empty. A NaN amount is rejected,
+// leaving the bucket unchanged, and is returned as the overflow.
+func (b *LeakyBucket) Add(amount float64) (overflow float64) {
+	if math.IsNaN(amount) {
+		return amount
+	}
+	for {
+		old := b.p.Load()
+		now := b.now()
+		if now.Before(old.at) {
+			now = old.at
+		}
+		level := math.Max(b.leaked(old, now)+amount, 0)
+		overflow = 0
+		if level > b.capacity {
+			overflow, level = level-b.capacity, b.capacity
+		}
+		if b.p.CompareAndSwap(old, &bucketState{tokens: level, at: now}) {
+			return overflow
+		}
+	}
+}
+
+// Level returns the level of the bucket at the current time.
+func (b *LeakyBucket) Level() float64 {
+	return b.leaked(b.p.Load(), b.now())
+}
@@ -0,0 +1,78 @@
+package atomic
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	// ErrNotPositive is returned by GeometricMean.Add for values that are
+	// not greater than zero, whose logarithms are not real numbers.
+	ErrNotPositive = errors.New("atomic: value must be positive")
+
+	// ErrZero is returned by HarmonicMean.Add for zero, whose reciprocal is
+	// infinite.
+	ErrZero = errors.New("atomic: value must not be zero")
+
+	// ErrNaN is returned by HarmonicMean.Add for NaN, which would otherwise
+	// make the mean NaN for good.
+	ErrNaN = errors.New("atomic: value must not be NaN")
+)
+
+// GeometricMean is a concurrency-safe accumulator of the geometric mean of a
+// stream of positive values, such as ratios. Rather than accumulating their
+// product, which quickly overflows or underflows, it accumulates the mean of
+// their logarithms. The zero value is an empty GeometricMean ready to use.
+type GeometricMean struct {
+	logs WeightedMean
+}
+
+// Add atomically includes x in the mean. Values that are not positive are
+// rejected with ErrNotPositive, leaving the mean unchanged.
+func (g *GeometricMean) Add(x float64) error {
+	if !(x > 0) {
+		return ErrNotPositive
+	}
+	return g.logs.Add(math.Log(x), 1)
+}
+
+// Mean returns the geometric mean of the values, or NaN when there are none.
+func (g *GeometricMean) Mean() float64 {
+	return math.Exp(g.logs.Mean())
+}
+
+// Count returns the number of values.
+func (g *GeometricMean) Count() float64 {
+	return g.logs.TotalWeight()
+}
+
+// HarmonicMean is a concurrency-safe accumulator of the harmonic mean of a
+// stream of non-zero values, such as rates. It accumulates the mean of their
+// reciprocals. Negative values are permitted, but the harmonic mean of values
+// of mixed signs is rarely meaningful. The zero value is an empty HarmonicMean
+// ready to use.
+type HarmonicMean struct {
+	reciprocals WeightedMean
+}
+
+// Add atomically includes x in the mean. Zero is rejected with ErrZero, and NaN
+// with ErrNaN, leaving the mean unchanged.
+func (h *HarmonicMean) Add(x float64) error {
+	if x == 0 {
+		return ErrZero
+	}
+	if x != x {
+		return ErrNaN
+	}
+	return h.reciprocals.Add(1/x, 1)
+}
+
+// Mean returns the harmonic mean of the values, or NaN when there are none.
+func (h *HarmonicMean) Mean() float64 {
+	return 1 / h.reciprocals.Mean()
+}
+
+// Count returns the number of values.
+func (h *HarmonicMean) Count() float64 {
+	return h.reciprocals.TotalWeight()
+}
@@ -0,0 +1,66 @@
+package atomic
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+)
+
+// MedianWindow is a concurrency-safe median of the most recent samples, which
+// unlike a moving average is robust to outliers. Observing samples is
+// serialized by a mutex, but the current median is published atomically, so
+// reading it never blocks.
+type MedianWindow struct {
+	median atomicFloatCAS
+	l      sync.Mutex
+	ring   []float64 // most recent samples, in the order they were observed
+	next   int       // index of the ring where the next sample is stored
+	count  int       // number of samples in the ring
+	sorted []float64 // samples in the ring, in ascending order
+}
+
+// NewMedianWindow returns a median over the most recent n samples. It panics
+// when n is not positive.
+func NewMedianWindow(n int) *MedianWindow {
+	if n <= 0 {
+		panic(fmt.Sprintf("atomic: NewMedianWindow window must be positive: %d", n))
+	}
+	m := &MedianWindow{ring: make([]float64, n), sorted: make([]float64, 0, n)}
+	m.median.Store(math.NaN())
+	return m
+}
+
+// Observe includes x in the window, evicting the oldest sample when the window
+// is full, and returns the new median. When the window holds an even number of
+// samples, the median is the mean of the two middle samples. NaN samples sort
+// before all others.
+func (m *MedianWindow) Observe(x float64) float64 {
+	m.l.Lock()
+	if m.count == len(m.ring) {
+		i, _ := slices.BinarySearch(m.sorted, m.ring[m.next])
+		m.sorted = slices.Delete(m.sorted, i, i+1)
+	} else {
+		m.count++
+	}
+	m.ring[m.next] = x
+	if m.next++; m.next == len(m.ring) {
+		m.next = 0
+	}
+	i, _ := slices.BinarySearch(m.sorted, x)
+	m.sorted = slices.Insert(m.sorted, i, x)
+
+	median := m.sorted[m.count/2]
+	if m.count%2 == 0 {
+		median = m.sorted[m.count/2-1]/2 + median/2
+	}
+	m.median.Store(median)
+	m.l.Unlock()
+	return median
+}
+
+// Median atomically loads the current median, or NaN when no samples have been
+// observed.
+func (m *MedianWindow) Median() float64 {
+	return m.median.Load()
+}
@@ -0,0 +1,62 @@
+package atomic
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// MovingAverage is a concurrency-safe simple moving average of the most recent
+// samples. Adding samples is serialized by a mutex, but the current average is
+// published atomically, so reading it never blocks.
+type MovingAverage struct {
+	average atomicFloatCAS
+	l       sync.Mutex
+	ring    []float64 // most recent samples
+	next    int       // index of the ring where the next sample is stored
+	count   int       // number of samples in the ring
+	sum     float64   // sum of the samples in the ring
+}
+
+// NewMovingAverage returns a moving average over the most recent window
+// samples. It panics when window is not positive.
+func NewMovingAverage(window int) *MovingAverage {
+	if window <= 0 {
+		panic(fmt.Sprintf("atomic: NewMovingAverage window must be positive: %d", window))
+	}
+	m := &MovingAverage{ring: make([]float64, window)}
+	m.average.Store(math.NaN())
+	return m
+}
+
+// Add includes x in the moving average, evicting the oldest sample when the
+// window is full, and returns the new average.
+func (m *MovingAverage) Add(x float64) float64 {
+	m.l.Lock()
+	if m.count == len(m.ring) {
+		m.sum -= m.ring[m.next]
+	} else {
+		m.count++
+	}
+	m.ring[m.next] = x
+	m.sum += x
+	if m.next++; m.next == len(m.ring) {
+		m.next = 0
+		// Recompute the sum once per pass over the ring, so that rounding
+		// error from repeated additions and subtractions cannot accumulate.
+		m.sum = 0
+		for _, v := range m.ring {
+			m.sum += v
+		}
+	}
+	average := m.sum / float64(m.count)
+	m.average.Store(average)
+	m.l.Unlock()
+	return average
+}
+
+// Average atomically loads the current moving average, or NaN when no samples
+// have been added.
+func (m *MovingAverage) Average() float64 {
+	return m.average.Load()
+}
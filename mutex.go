@@ -1,10 +1,18 @@
 package atomic
 
-import "sync"
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+)
 
 type atomicFloatMutex struct {
-	f64 float64
-	l   sync.RWMutex
+	f64    float64
+	l      sync.RWMutex
+	format textFormat
 }
 
 func NewAtomicFloatMutex(initial float64) *atomicFloatMutex {
@@ -21,6 +29,168 @@ func (a *atomicFloatMutex) Add(delta float64) float64 {
 	return new
 }
 
+// AddAndSwap attempts to add delta to the value stored in the atomic float and
+// return both the value it replaced and the new value, from the same atomic
+// update.
+func (a *atomicFloatMutex) AddAndSwap(delta float64) (old, new float64) {
+	a.l.Lock()
+	old = a.f64
+	a.f64 += delta
+	new = a.f64
+	a.l.Unlock()
+	return old, new
+}
+
+// AddChecked attempts to add delta to the value stored in the atomic float and
+// return the new value. When the new value would be NaN, or ±Inf, and the
+// stored value was not already, the stored value is left untouched, and
+// AddChecked returns it along with ErrNaNResult or ErrInfResult.
+func (a *atomicFloatMutex) AddChecked(delta float64) (float64, error) {
+	a.l.Lock()
+	defer a.l.Unlock()
+	new := a.f64 + delta
+	if err := checkResult(a.f64, new); err != nil {
+		return a.f64, err
+	}
+	a.f64 = new
+	return new, nil
+}
+
+// Sub attempts to subtract delta from the value stored in the atomic float and
+// return the new value.
+func (a *atomicFloatMutex) Sub(delta float64) float64 {
+	a.l.Lock()
+	a.f64 -= delta
+	new := a.f64
+	a.l.Unlock()
+	return new
+}
+
+// Mul attempts to multiply the value stored in the atomic float by factor and
+// return the new value.
+func (a *atomicFloatMutex) Mul(factor float64) float64 {
+	a.l.Lock()
+	a.f64 *= factor
+	new := a.f64
+	a.l.Unlock()
+	return new
+}
+
+// Div attempts to divide the value stored in the atomic float by divisor and
+// return the new value. Division by zero does not panic, but follows IEEE 754
+// semantics, producing ±Inf, or NaN when the stored value is also zero.
+func (a *atomicFloatMutex) Div(divisor float64) float64 {
+	a.l.Lock()
+	a.f64 /= divisor
+	new := a.f64
+	a.l.Unlock()
+	return new
+}
+
+// Max atomically stores v when it is greater than the value stored in the atomic
+// float, and returns the resulting value. As with math.Max, when either v or
+// the stored value is NaN the result is NaN, and +0 is considered greater than
+// -0.
+func (a *atomicFloatMutex) Max(v float64) float64 {
+	a.l.Lock()
+	a.f64 = math.Max(a.f64, v)
+	new := a.f64
+	a.l.Unlock()
+	return new
+}
+
+// Min atomically stores v when it is less than the value stored in the atomic
+// float, and returns the resulting value. As with math.Min, when either v or
+// the stored value is NaN the result is NaN, and -0 is considered less than
+// +0.
+func (a *atomicFloatMutex) Min(v float64) float64 {
+	a.l.Lock()
+	a.f64 = math.Min(a.f64, v)
+	new := a.f64
+	a.l.Unlock()
+	return new
+}
+
+// MaxIgnoreNaN atomically stores v when it is greater than the value stored in
+// the atomic float, and returns the resulting value. Unlike Max, which follows
+// math.Max, a NaN v is ignored, leaving the stored value unchanged, and a
+// stored NaN is treated as unset, so it is replaced by the next v that is not
+// NaN.
+func (a *atomicFloatMutex) MaxIgnoreNaN(v float64) float64 {
+	if math.IsNaN(v) {
+		return a.Load()
+	}
+	a.l.Lock()
+	a.f64 = maxIgnoreNaN(a.f64, v)
+	new := a.f64
+	a.l.Unlock()
+	return new
+}
+
+// MinIgnoreNaN atomically stores v when it is less than the value stored in
+// the atomic float, and returns the resulting value. Unlike Min, which follows
+// math.Min, a NaN v is ignored, leaving the stored value unchanged, and a
+// stored NaN is treated as unset, so it is replaced by the next v that is not
+// NaN.
+func (a *atomicFloatMutex) MinIgnoreNaN(v float64) float64 {
+	if math.IsNaN(v) {
+		return a.Load()
+	}
+	a.l.Lock()
+	a.f64 = minIgnoreNaN(a.f64, v)
+	new := a.f64
+	a.l.Unlock()
+	return new
+}
+
+// Update atomically replaces the value stored in the atomic float with the
+// result of calling fn with the current value, and returns the new value. fn
+// is invoked exactly once while holding the write lock, so it must not
+// call back into the atomic float.
+func (a *atomicFloatMutex) Update(fn func(old float64) (new float64)) float64 {
+	a.l.Lock()
+	a.f64 = fn(a.f64)
+	new := a.f64
+	a.l.Unlock()
+	return new
+}
+
+// TryAdd attempts to add delta to the value stored in the atomic float without
+// blocking. It returns the new value and true when the add took place, or zero
+// and false when the lock was held by another goroutine, in which case the
+// stored value is left untouched. This makes the write path of the mutex
+// implementation optionally non-blocking, although a TryAdd that acquires the
+// lock still excludes other readers and writers while it holds it.
+func (a *atomicFloatMutex) TryAdd(delta float64) (float64, bool) {
+	if !a.l.TryLock() {
+		return 0, false
+	}
+	a.f64 += delta
+	new := a.f64
+	a.l.Unlock()
+	return new, true
+}
+
+// Inc atomically increments the value stored in the atomic float by one and
+// returns the new value.
+func (a *atomicFloatMutex) Inc() float64 {
+	a.l.Lock()
+	a.f64++
+	new := a.f64
+	a.l.Unlock()
+	return new
+}
+
+// Dec atomically decrements the value stored in the atomic float by one and
+// returns the new value.
+func (a *atomicFloatMutex) Dec() float64 {
+	a.l.Lock()
+	a.f64--
+	new := a.f64
+	a.l.Unlock()
+	return new
+}
+
 // Load atomically loads the current atomic float value.
 func (a *atomicFloatMutex) Load() float64 {
 	a.l.RLock()
@@ -44,3 +214,214 @@ func (a *atomicFloatMutex) Swap(new float64) float64 {
 	a.l.Unlock()
 	return old
 }
+
+// Bits atomically loads the IEEE 754 binary representation of the current
+// atomic float value.
+func (a *atomicFloatMutex) Bits() uint64 {
+	a.l.RLock()
+	bits := math.Float64bits(a.f64)
+	a.l.RUnlock()
+	return bits
+}
+
+// Int64 atomically loads the current atomic float value and returns it
+// truncated toward zero, clamped to the range of int64, or 0 when it is NaN.
+func (a *atomicFloatMutex) Int64() int64 {
+	return toInt64(math.Trunc(a.Load()))
+}
+
+// Round atomically loads the current atomic float value and returns it rounded
+// to the nearest integer, with halves rounded to even, clamped to the range of
+// int64, or 0 when it is NaN.
+func (a *atomicFloatMutex) Round() int64 {
+	return toInt64(math.RoundToEven(a.Load()))
+}
+
+// Reset atomically stores 0 into the atomic float.
+func (a *atomicFloatMutex) Reset() {
+	a.l.Lock()
+	a.f64 = 0
+	a.l.Unlock()
+}
+
+// LoadAndReset atomically stores 0 into the atomic float and returns the
+// previous value.
+func (a *atomicFloatMutex) LoadAndReset() float64 {
+	a.l.Lock()
+	old := a.f64
+	a.f64 = 0
+	a.l.Unlock()
+	return old
+}
+
+// DrainIfAtLeast atomically stores 0 into the atomic float and returns the
+// previous value and true, when the previous value is at least threshold.
+// Otherwise it leaves the value untouched, and returns it and false. NaN is
+// never at least any threshold.
+func (a *atomicFloatMutex) DrainIfAtLeast(threshold float64) (float64, bool) {
+	a.l.Lock()
+	old := a.f64
+	drained := old >= threshold
+	if drained {
+		a.f64 = 0
+	}
+	a.l.Unlock()
+	return old, drained
+}
+
+// Clone returns a new, independent atomic float initialized to the current
+// value.
+func (a *atomicFloatMutex) Clone() *atomicFloatMutex {
+	a.l.RLock()
+	b := &atomicFloatMutex{f64: a.f64}
+	a.l.RUnlock()
+	b.format.bits.Store(a.format.bits.Load())
+	return b
+}
+
+// CompareAndSwap atomically stores new when the current value is old, and
+// returns true when the swap took place. Values are compared by their bit
+// patterns, so +0 and -0 are distinct. Because NaN never compares equal to
+// anything, CompareAndSwap with old equal to NaN never succeeds.
+func (a *atomicFloatMutex) CompareAndSwap(old, new float64) bool {
+	if old != old {
+		return false // NaN
+	}
+	a.l.Lock()
+	swapped := math.Float64bits(a.f64) == math.Float64bits(old)
+	if swapped {
+		a.f64 = new
+	}
+	a.l.Unlock()
+	return swapped
+}
+
+// CompareAndSwapEpsilon atomically stores new when the current value is within
+// epsilon of old, and returns true when the swap took place. A negative epsilon
+// is treated as its absolute value, and an epsilon of zero performs the same
+// exact comparison as CompareAndSwap.
+func (a *atomicFloatMutex) CompareAndSwapEpsilon(old, new, epsilon float64) bool {
+	if epsilon == 0 {
+		return a.CompareAndSwap(old, new)
+	}
+	a.l.Lock()
+	swapped := math.Abs(a.f64-old) <= math.Abs(epsilon)
+	if swapped {
+		a.f64 = new
+	}
+	a.l.Unlock()
+	return swapped
+}
+
+// SetFormat changes the strconv.FormatFloat verb and precision with which
+// String and MarshalText format the atomic float value, which by default are
+// 'g' and -1, the shortest representation that parses back to the same value.
+// It may be called concurrently with formatting, and panics when verb is not
+// one of 'b', 'e', 'E', 'f', 'g', 'G', 'x', or 'X', or prec is less than -1.
+func (a *atomicFloatMutex) SetFormat(verb byte, prec int) {
+	a.format.set(verb, prec)
+}
+
+// String returns the current atomic float value formatted as configured by
+// SetFormat, by default in the shortest representation that parses back to
+// the same value.
+func (a *atomicFloatMutex) String() string {
+	return string(a.format.appendFloat(nil, a.Load()))
+}
+
+// Format implements fmt.Formatter, formatting the current atomic float value
+// as fmt would format a float64, including its width, precision, and flags.
+// Verbs that are not meaningful for floating point values print the value as
+// if by the 'v' verb.
+func (a *atomicFloatMutex) Format(s fmt.State, verb rune) {
+	formatFloat(s, verb, a.Load())
+}
+
+// MarshalJSON implements json.Marshaler, encoding the current atomic float value
+// as a JSON number. Because JSON cannot represent NaN or ±Inf, those values are
+// encoded as null.
+func (a *atomicFloatMutex) MarshalJSON() ([]byte, error) {
+	return appendJSONFloat(nil, a.Load()), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, atomically storing the JSON number
+// in b into the atomic float. As is conventional, null leaves the value
+// unchanged. Numbers that overflow a float64 are rejected with an error.
+func (a *atomicFloatMutex) UnmarshalJSON(b []byte) error {
+	if a == nil {
+		return errNilUnmarshal("UnmarshalJSON")
+	}
+	f, ok, err := parseJSONFloat(b)
+	if ok {
+		a.Store(f)
+	}
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the current atomic
+// float value as configured by SetFormat, by default in the shortest
+// representation that parses back to the same value. Unlike JSON, NaN and ±Inf
+// are preserved.
+func (a *atomicFloatMutex) MarshalText() ([]byte, error) {
+	return a.format.appendFloat(nil, a.Load()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing b as a floating
+// point number, including NaN and ±Inf, and atomically storing it into the
+// atomic float.
+func (a *atomicFloatMutex) UnmarshalText(b []byte) error {
+	if a == nil {
+		return errNilUnmarshal("UnmarshalText")
+	}
+	f, err := parseTextFloat(b)
+	if err != nil {
+		return err
+	}
+	a.Store(f)
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, encoding the IEEE 754
+// binary representation of the current atomic float value as 8 big-endian
+// bytes. This preserves every bit pattern exactly, including NaN payloads and
+// the sign of zero.
+func (a *atomicFloatMutex) MarshalBinary() ([]byte, error) {
+	return binary.BigEndian.AppendUint64(nil, a.Bits()), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, atomically storing
+// the value whose IEEE 754 binary representation is encoded as the 8
+// big-endian bytes in b.
+func (a *atomicFloatMutex) UnmarshalBinary(b []byte) error {
+	if a == nil {
+		return errNilUnmarshal("UnmarshalBinary")
+	}
+	if len(b) != 8 {
+		return errBinaryLength(len(b))
+	}
+	a.Store(math.Float64frombits(binary.BigEndian.Uint64(b)))
+	return nil
+}
+
+// Scan implements sql.Scanner, atomically storing the database value src into
+// the atomic float. src may be a float64, int64, or a []byte or string holding
+// a number. A NULL src leaves the value unchanged.
+func (a *atomicFloatMutex) Scan(src any) error {
+	f, ok, err := parseSQLFloat(src)
+	if ok {
+		a.Store(f)
+	}
+	return err
+}
+
+// Value implements driver.Valuer, returning the current atomic float value as
+// a float64.
+func (a *atomicFloatMutex) Value() (driver.Value, error) {
+	return a.Load(), nil
+}
+
+// LogValue implements slog.LogValuer, so that structured logs record the
+// current atomic float value as a number.
+func (a *atomicFloatMutex) LogValue() slog.Value {
+	return slog.Float64Value(a.Load())
+}
@@ -0,0 +1,65 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// atomicFloatNeumaier is an atomic float accumulator that uses Neumaier's
+// improvement of Kahan summation, which also retains the low-order bits lost
+// when the value being added is larger in magnitude than the running sum. Load
+// returns the running sum, while LoadExact also applies the accumulated
+// compensation. Like atomicFloatKahanCAS, every Add allocates.
+type atomicFloatNeumaier struct {
+	p atomic.Pointer[compensatedSum]
+}
+
+func NewAtomicFloatNeumaier(initial float64) *atomicFloatNeumaier {
+	a := new(atomicFloatNeumaier)
+	a.p.Store(&compensatedSum{sum: initial})
+	return a
+}
+
+// Add attempts to add delta to the value stored in the atomic float and return
+// the new running sum.
+func (a *atomicFloatNeumaier) Add(delta float64) float64 {
+	for {
+		old := a.p.Load()
+		t := old.sum + delta
+		c := old.c
+		if math.Abs(old.sum) >= math.Abs(delta) {
+			c += (old.sum - t) + delta
+		} else {
+			c += (delta - t) + old.sum
+		}
+		if a.p.CompareAndSwap(old, &compensatedSum{sum: t, c: c}) {
+			return t
+		}
+	}
+}
+
+// Load atomically loads the current running sum, without applying the
+// accumulated compensation.
+func (a *atomicFloatNeumaier) Load() float64 {
+	return a.p.Load().sum
+}
+
+// LoadExact atomically loads the current running sum and returns it with the
+// accumulated compensation applied, for maximum accuracy.
+func (a *atomicFloatNeumaier) LoadExact() float64 {
+	s := a.p.Load()
+	return s.sum + s.c
+}
+
+// Store atomically stores new into the atomic float, discarding any
+// accumulated compensation.
+func (a *atomicFloatNeumaier) Store(new float64) {
+	a.p.Store(&compensatedSum{sum: new})
+}
+
+// Swap atomically stores new and returns the previous value with its
+// accumulated compensation applied.
+func (a *atomicFloatNeumaier) Swap(new float64) float64 {
+	s := a.p.Swap(&compensatedSum{sum: new})
+	return s.sum + s.c
+}
@@ -0,0 +1,108 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Float is a constraint that permits any floating point type.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number is an atomic floating point number of either width. A float32 Number
+// stores its bits in u32 using 32-bit atomic operations, while a float64 Number
+// stores its bits in u64 using 64-bit atomic operations. The storage not
+// selected by the size of T is never used.
+type Number[T Float] struct {
+	u64 atomic.Uint64
+	u32 atomic.Uint32
+}
+
+func NewNumber[T Float](initial T) *Number[T] {
+	n := new(Number[T])
+	n.Store(initial)
+	return n
+}
+
+// is32 returns true when T is a 32-bit floating point type.
+func (n *Number[T]) is32() bool {
+	return unsafe.Sizeof(T(0)) == 4
+}
+
+func (n *Number[T]) toBits(f T) uint64 {
+	if n.is32() {
+		return uint64(math.Float32bits(float32(f)))
+	}
+	return math.Float64bits(float64(f))
+}
+
+func (n *Number[T]) fromBits(bits uint64) T {
+	if n.is32() {
+		return T(math.Float32frombits(uint32(bits)))
+	}
+	return T(math.Float64frombits(bits))
+}
+
+func (n *Number[T]) loadBits() uint64 {
+	if n.is32() {
+		return uint64(n.u32.Load())
+	}
+	return n.u64.Load()
+}
+
+func (n *Number[T]) casBits(oldBits, newBits uint64) bool {
+	if n.is32() {
+		return n.u32.CompareAndSwap(uint32(oldBits), uint32(newBits))
+	}
+	return n.u64.CompareAndSwap(oldBits, newBits)
+}
+
+// Add attempts to add delta to the value stored in the atomic number and return
+// the new value.
+func (n *Number[T]) Add(delta T) T {
+	var newValue T
+	var oldBits, newBits uint64
+	for {
+		oldBits = n.loadBits()
+		newValue = n.fromBits(oldBits) + delta
+		newBits = n.toBits(newValue)
+		if n.casBits(oldBits, newBits) {
+			return newValue
+		}
+	}
+}
+
+// Load atomically loads the current atomic number value.
+func (n *Number[T]) Load() T {
+	return n.fromBits(n.loadBits())
+}
+
+// Store atomically stores new into the atomic number.
+func (n *Number[T]) Store(new T) {
+	if n.is32() {
+		n.u32.Store(uint32(n.toBits(new)))
+		return
+	}
+	n.u64.Store(n.toBits(new))
+}
+
+// Swap atomically stores new and returns the previous value.
+func (n *Number[T]) Swap(new T) T {
+	if n.is32() {
+		return n.fromBits(uint64(n.u32.Swap(uint32(n.toBits(new)))))
+	}
+	return n.fromBits(n.u64.Swap(n.toBits(new)))
+}
+
+// CompareAndSwap atomically stores new when the current value is old, and
+// returns true when the swap took place. Values are compared by their bit
+// patterns, so +0 and -0 are distinct. Because NaN never compares equal to
+// anything, CompareAndSwap with old equal to NaN never succeeds.
+func (n *Number[T]) CompareAndSwap(old, new T) bool {
+	if old != old {
+		return false // NaN
+	}
+	return n.casBits(n.toBits(old), n.toBits(new))
+}
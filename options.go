@@ -0,0 +1,133 @@
+package atomic
+
+import "fmt"
+
+// Implementation identifies an atomic float implementation that NewAtomicFloat
+// may construct.
+type Implementation int
+
+const (
+	// ImplementationAuto lets NewAtomicFloat choose the implementation from
+	// the expected number of writers. It is the default.
+	ImplementationAuto Implementation = iota
+
+	// ImplementationCAS selects the compare-and-swap implementation, which
+	// is fastest when writers rarely contend.
+	ImplementationCAS
+
+	// ImplementationCAS2 selects the alternate compare-and-swap
+	// implementation.
+	ImplementationCAS2
+
+	// ImplementationMutex selects the mutex implementation.
+	ImplementationMutex
+
+	// ImplementationSharded selects the sharded implementation, whose adds
+	// scale with many writers at the expense of slower loads.
+	ImplementationSharded
+)
+
+// shardedWriters is the number of expected writers at and above which
+// NewAtomicFloat selects the sharded implementation.
+const shardedWriters = 8
+
+// options are the settings configured by Option values.
+type options struct {
+	expectedWriters int
+	implementation  Implementation
+
+	// The remaining settings tune the compare-and-swap implementation.
+	maxRetries   int
+	backoff      Backoff
+	strictFinite bool
+}
+
+// newOptions returns the settings configured by opts, applied in order, so
+// that later options override earlier ones.
+func newOptions(opts []Option) *options {
+	o := new(options)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// tunesCAS returns true when any setting that only the compare-and-swap
+// implementation honors is configured.
+func (o *options) tunesCAS() bool {
+	return o.maxRetries > 0 || o.backoff != nil || o.strictFinite
+}
+
+// Option configures an atomic float constructor. Options that a constructor
+// does not document as applying to it have no effect on it.
+type Option func(*options)
+
+// WithExpectedWriters hints that about n goroutines will write the atomic float
+// concurrently, to help NewAtomicFloat choose an implementation.
+func WithExpectedWriters(n int) Option {
+	return func(o *options) { o.expectedWriters = n }
+}
+
+// WithImplementation makes NewAtomicFloat construct impl, overriding its choice
+// based on the expected number of writers.
+func WithImplementation(impl Implementation) Option {
+	return func(o *options) { o.implementation = impl }
+}
+
+// WithMaxRetries makes TryAdd give up after n failed compare-and-swap
+// operations when it is not given a positive limit of its own. A non-positive
+// n retries until success. It applies to the compare-and-swap implementation.
+func WithMaxRetries(n int) Option {
+	return func(o *options) { o.maxRetries = n }
+}
+
+// WithBackoff makes every retry loop call b.Pause after each failed
+// compare-and-swap operation, to back off under contention. A nil b retries
+// immediately. It applies to the compare-and-swap implementation.
+func WithBackoff(b Backoff) Option {
+	return func(o *options) { o.backoff = b }
+}
+
+// WithStrictFinite makes every write that would store NaN or ±Inf leave the
+// stored value untouched instead. Operations that return the new value return
+// the untouched value, Store does nothing, CompareAndSwap returns false, and
+// the unmarshaling methods return an error wrapping ErrNotFinite. The
+// constructor panics when the initial value is not finite. It applies to the
+// compare-and-swap implementation.
+func WithStrictFinite() Option {
+	return func(o *options) { o.strictFinite = true }
+}
+
+// NewAtomicFloat returns an atomic float initialized to initial, using the
+// implementation best suited to the expected contention. Unless overridden by
+// WithImplementation, it returns the sharded implementation when at least 8
+// writers are expected, as their adds would otherwise contend on a single
+// word, and the compare-and-swap implementation otherwise, including when no
+// hint is given. Options tuning the compare-and-swap implementation are
+// passed through to it, and select it when no implementation is specified. It
+// panics when given an unknown Implementation, or such options along with a
+// different implementation, which could not honor them.
+func NewAtomicFloat(initial float64, opts ...Option) AtomicFloat {
+	o := newOptions(opts)
+	impl := o.implementation
+	if impl == ImplementationAuto {
+		impl = ImplementationCAS
+		if o.expectedWriters >= shardedWriters && !o.tunesCAS() {
+			impl = ImplementationSharded
+		}
+	}
+	if impl != ImplementationCAS && o.tunesCAS() {
+		panic(fmt.Sprintf("atomic: NewAtomicFloat options only apply to ImplementationCAS: %d", impl))
+	}
+	switch impl {
+	case ImplementationCAS:
+		return NewAtomicFloatCAS(initial, opts...)
+	case ImplementationCAS2:
+		return NewAtomicFloatCAS2(initial)
+	case ImplementationMutex:
+		return NewAtomicFloatMutex(initial)
+	case ImplementationSharded:
+		return NewAtomicFloatSharded(initial)
+	}
+	panic(fmt.Sprintf("atomic: NewAtomicFloat unknown implementation: %d", impl))
+}
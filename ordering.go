@@ -0,0 +1,42 @@
+package atomic
+
+// The Go memory model provides only sequentially consistent atomic
+// operations: if the effect of an atomic operation A is observed by an atomic
+// operation B, then A is synchronized before B, and all atomic operations
+// behave as though executed in some single total order. Go cannot express
+// weaker orderings, such as the acquire, release, and relaxed orderings of
+// C++, so the methods in this file are aliases of Load and Store that name
+// the guarantee an algorithm depends on, for readers who reason in those
+// terms. They are never cheaper than Load and Store, but neither are they
+// weaker, so algorithms designed for the weaker orderings remain correct.
+
+// LoadAcquire atomically loads the current atomic float value, exactly like
+// Load. When it observes a value written by StoreRelease, or any other write to
+// the atomic float, that write happens before LoadAcquire returns, so every
+// memory write preceding it in the writing goroutine is visible to the
+// goroutine calling LoadAcquire.
+func (a *atomicFloatCAS) LoadAcquire() float64 {
+	return a.Load()
+}
+
+// StoreRelease atomically stores new into the atomic float, exactly like Store.
+// Every memory write preceding StoreRelease in the calling goroutine happens
+// before any load of the atomic float that observes new.
+func (a *atomicFloatCAS) StoreRelease(new float64) {
+	a.Store(new)
+}
+
+// LoadRelaxed atomically loads the current atomic float value, exactly like
+// Load. A relaxed load would guarantee only that the value is not torn, and
+// impose no ordering on other memory. Go offers no such operation, so
+// LoadRelaxed is in fact sequentially consistent; the name documents that the
+// caller depends on nothing more than a value that is not torn.
+func (a *atomicFloatCAS) LoadRelaxed() float64 {
+	return a.Load()
+}
+
+// StoreRelaxed atomically stores new into the atomic float, exactly like Store.
+// As with LoadRelaxed, it is in fact sequentially consistent.
+func (a *atomicFloatCAS) StoreRelaxed(new float64) {
+	a.Store(new)
+}
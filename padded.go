@@ -0,0 +1,17 @@
+package atomic
+
+import "unsafe"
+
+// PaddedAtomicFloatCAS is an atomicFloatCAS padded to fill an entire cache line,
+// so that instances stored next to each other, such as in an array or struct,
+// do not contend with one another through false sharing.
+type PaddedAtomicFloatCAS struct {
+	atomicFloatCAS
+	_ [cacheLineSize - unsafe.Sizeof(atomicFloatCAS{})%cacheLineSize]byte
+}
+
+func NewPaddedAtomicFloatCAS(initial float64) *PaddedAtomicFloatCAS {
+	a := new(PaddedAtomicFloatCAS)
+	a.Store(initial)
+	return a
+}
@@ -0,0 +1,47 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// atomicFloatPauseCAS is an atomic float whose Add retry loop executes a
+// processor spin-wait hint between failed compare-and-swap attempts. On amd64
+// this is the PAUSE instruction; on other architectures the goroutine yields
+// the processor instead.
+type atomicFloatPauseCAS struct{ u64 atomic.Uint64 }
+
+func NewAtomicFloatPauseCAS(initial float64) *atomicFloatPauseCAS {
+	a := new(atomicFloatPauseCAS)
+	a.u64.Store(math.Float64bits(initial))
+	return a
+}
+
+// Add attempts to add delta to the value stored in the atomic float and return
+// the new value.
+func (a *atomicFloatPauseCAS) Add(delta float64) float64 {
+loop:
+	oldBits := a.u64.Load()
+	newValue := math.Float64frombits(oldBits) + delta
+	newBits := math.Float64bits(newValue)
+	if !a.u64.CompareAndSwap(oldBits, newBits) {
+		spinPause()
+		goto loop
+	}
+	return newValue
+}
+
+// Load atomically loads the current atomic float value.
+func (a *atomicFloatPauseCAS) Load() float64 {
+	return math.Float64frombits(a.u64.Load())
+}
+
+// Store atomically stores new into the atomic float.
+func (a *atomicFloatPauseCAS) Store(new float64) {
+	a.u64.Store(math.Float64bits(new))
+}
+
+// Swap atomically stores new and returns the previous value.
+func (a *atomicFloatPauseCAS) Swap(new float64) float64 {
+	return math.Float64frombits(a.u64.Swap(math.Float64bits(new)))
+}
@@ -0,0 +1,17 @@
+package atomic
+
+// spinPauseCycles is the number of PAUSE instructions executed by spinPause,
+// matching the Go runtime's own active spinning.
+const spinPauseCycles = 30
+
+// procyield executes the PAUSE instruction cycles times, which must be greater
+// than zero. PAUSE hints to the processor that the caller is in a spin-wait
+// loop, which reduces power consumption and avoids a memory order violation
+// penalty when the loop exits.
+func procyield(cycles uint32)
+
+// spinPause briefly pauses the caller between failed compare-and-swap
+// attempts, without yielding the processor.
+func spinPause() {
+	procyield(spinPauseCycles)
+}
@@ -0,0 +1,12 @@
+//go:build !amd64
+
+package atomic
+
+import "runtime"
+
+// spinPause briefly pauses the caller between failed compare-and-swap
+// attempts. Without a processor-specific pause instruction available, it yields
+// the processor to other goroutines.
+func spinPause() {
+	runtime.Gosched()
+}
@@ -0,0 +1,67 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Product is a concurrency-safe running product, such as of many
+// probabilities. Rather than multiplying the factors directly, which quickly
+// underflows to zero or overflows to infinity, it accumulates the sum of the
+// logarithms of their magnitudes, tracking the sign and any zero factor
+// separately. Intermediate results may therefore be far outside the range of a
+// float64 so long as the final product is not. The zero value is an empty
+// product, whose value is 1, ready to use.
+//
+// The sum, sign, and zero flag are updated independently, so a Value that is
+// concurrent with Mul may reflect the factor in some but not all of them.
+type Product struct {
+	logs      atomicFloatCAS
+	negatives atomic.Uint64 // number of negative factors
+	zero      atomic.Bool   // whether any factor was zero
+}
+
+// Mul atomically multiplies the product by factor. Once multiplied by zero,
+// the product remains zero. Multiplying by NaN makes the product NaN, unless it
+// is zero.
+func (p *Product) Mul(factor float64) {
+	if factor == 0 {
+		p.zero.Store(true)
+	}
+	if math.Signbit(factor) {
+		p.negatives.Add(1)
+	}
+	if factor != 0 {
+		p.logs.Add(math.Log(math.Abs(factor)))
+	}
+}
+
+// negative returns true when the product is negative.
+func (p *Product) negative() bool {
+	return p.negatives.Load()%2 == 1
+}
+
+// Value returns the product of the factors as math.Exp of the accumulated
+// logarithms, so it underflows to zero or overflows to infinity whenever the
+// product itself is outside the range of a float64, just as a naive product
+// would. Use LogAbs for products that may be that small or large.
+func (p *Product) Value() float64 {
+	var v float64
+	if !p.zero.Load() {
+		v = math.Exp(p.logs.Load())
+	}
+	if p.negative() {
+		v = -v
+	}
+	return v
+}
+
+// LogAbs returns the natural logarithm of the magnitude of the product, which
+// remains representable long after Value underflows or overflows. It returns
+// -Inf when the product is zero.
+func (p *Product) LogAbs() float64 {
+	if p.zero.Load() {
+		return math.Inf(-1)
+	}
+	return p.logs.Load()
+}
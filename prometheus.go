@@ -0,0 +1,41 @@
+//go:build prometheus
+
+package atomic
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// PrometheusAdapter is a prometheus.Collector that exposes the value of an
+// AtomicFloat as a single gauge or counter metric. It is only built with the
+// prometheus build tag, so that the package remains free of dependencies
+// otherwise.
+type PrometheusAdapter struct {
+	desc      *prometheus.Desc
+	af        AtomicFloat
+	valueType prometheus.ValueType
+}
+
+var _ prometheus.Collector = (*PrometheusAdapter)(nil)
+
+// NewGaugeAdapter returns a prometheus.Collector that exposes the value of af
+// as a gauge described by desc.
+func NewGaugeAdapter(desc *prometheus.Desc, af AtomicFloat) *PrometheusAdapter {
+	return &PrometheusAdapter{desc: desc, af: af, valueType: prometheus.GaugeValue}
+}
+
+// NewCounterAdapter returns a prometheus.Collector that exposes the value of
+// af as a counter described by desc. Prometheus requires counters to never
+// decrease, so af should only ever have non-negative values added to it.
+func NewCounterAdapter(desc *prometheus.Desc, af AtomicFloat) *PrometheusAdapter {
+	return &PrometheusAdapter{desc: desc, af: af, valueType: prometheus.CounterValue}
+}
+
+// Describe implements prometheus.Collector.
+func (p *PrometheusAdapter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.desc
+}
+
+// Collect implements prometheus.Collector, atomically loading the current
+// value of the atomic float.
+func (p *PrometheusAdapter) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(p.desc, p.valueType, p.af.Load())
+}
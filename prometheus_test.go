@@ -0,0 +1,41 @@
+//go:build prometheus
+
+package atomic
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestPrometheusAdapter(t *testing.T) {
+	gauge, counter := NewAtomicFloatMutex(0.5), NewAtomicFloatCAS(0)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		NewGaugeAdapter(prometheus.NewDesc("test_gauge", "A test gauge.", nil, nil), gauge),
+		NewCounterAdapter(prometheus.NewDesc("test_counter", "A test counter.", nil, nil), counter),
+	)
+
+	parallel(100, func(int) {
+		for i := 0; i < 100; i++ {
+			gauge.Add(-1)
+			counter.Add(1)
+		}
+	})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(families), 2; got != want {
+		t.Fatalf("GOT: %v; WANT: %v", got, want)
+	}
+	// Gather sorts the metric families by name.
+	if got, want := families[0].GetMetric()[0].GetCounter().GetValue(), 10000.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := families[1].GetMetric()[0].GetGauge().GetValue(), -9999.5; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package atomic
+
+import "sync/atomic"
+
+// atomicFloatPtr is an atomic float that stores its value behind a pointer,
+// rather than packed into a uint64, and updates it by swapping in a pointer to
+// a freshly allocated value. Every Add therefore allocates, once per attempt,
+// but the same technique extends to state wider than a single 64-bit word, as
+// in the compensated sums and TimestampedFloat.
+type atomicFloatPtr struct {
+	p atomic.Pointer[float64]
+}
+
+func NewAtomicFloatPtr(initial float64) *atomicFloatPtr {
+	a := new(atomicFloatPtr)
+	a.p.Store(&initial)
+	return a
+}
+
+// atomicFloatRCU is an atomic float for configuration-like values that are read
+// on every request but rarely written. It follows the read-copy-update
+// pattern, which atomicFloatPtr already implements: each value, once
+// published, is never modified, so Load is a single pointer load, which is
+// wait-free, while writers allocate a new value and swap in a pointer to it.
+type atomicFloatRCU = atomicFloatPtr
+
+// NewAtomicFloatRCU returns a read-copy-update atomic float initialized to
+// initial.
+func NewAtomicFloatRCU(initial float64) *atomicFloatRCU {
+	return NewAtomicFloatPtr(initial)
+}
+
+// Add attempts to add delta to the value stored in the atomic float and return
+// the new value.
+func (a *atomicFloatPtr) Add(delta float64) float64 {
+	for {
+		old := a.p.Load()
+		new := *old + delta
+		if a.p.CompareAndSwap(old, &new) {
+			return new
+		}
+	}
+}
+
+// Load atomically loads the current atomic float value. It is wait-free.
+func (a *atomicFloatPtr) Load() float64 {
+	return *a.p.Load()
+}
+
+// Store atomically stores new into the atomic float.
+func (a *atomicFloatPtr) Store(new float64) {
+	a.p.Store(&new)
+}
+
+// Swap atomically stores new and returns the previous value.
+func (a *atomicFloatPtr) Swap(new float64) float64 {
+	return *a.p.Swap(&new)
+}
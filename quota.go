@@ -0,0 +1,96 @@
+package atomic
+
+import (
+	"fmt"
+	"math"
+)
+
+// Quota is a concurrency-safe budget, such as of API calls, whose balance is
+// spent down to zero and refilled up to a cap, and never leaves [0, cap]. Each
+// operation is a single compare-and-swap loop, so concurrent spends never
+// overdraw the balance, and concurrent refills never exceed the cap.
+type Quota struct {
+	af       atomicFloatCAS
+	capacity float64
+}
+
+// NewQuota returns a new Quota with a balance of initial, clamped to [0,
+// capacity], or of zero when initial is NaN. It panics when capacity is
+// negative or not finite.
+func NewQuota(initial, capacity float64) *Quota {
+	if !(capacity >= 0) || math.IsInf(capacity, 0) {
+		panic(fmt.Sprintf("atomic: NewQuota cap must be finite and not negative: %v", capacity))
+	}
+	q := &Quota{capacity: capacity}
+	if initial > 0 {
+		q.af.Store(math.Min(initial, capacity))
+	}
+	return q
+}
+
+// update atomically replaces the balance with the result of fn, and returns
+// the old and new balances. When fn returns the old balance, nothing is
+// written.
+func (q *Quota) update(fn func(old float64) float64) (float64, float64) {
+	for {
+		oldBits := q.af.u64.Load()
+		oldValue := math.Float64frombits(oldBits)
+		newValue := fn(oldValue)
+		if newValue == oldValue || q.af.u64.CompareAndSwap(oldBits, math.Float64bits(newValue)) {
+			return oldValue, newValue
+		}
+	}
+}
+
+// Spend atomically deducts n from the balance and returns true, or returns
+// false, deducting nothing, when the balance is less than n or n is NaN. A
+// negative n is treated as zero.
+func (q *Quota) Spend(n float64) bool {
+	if math.IsNaN(n) {
+		return false
+	}
+	n = math.Max(n, 0)
+	oldValue, _ := q.update(func(old float64) float64 {
+		if old < n {
+			return old
+		}
+		return old - n
+	})
+	return oldValue >= n
+}
+
+// SpendUpTo atomically deducts up to n from the balance, and returns the amount
+// actually spent, which is less than n when the balance was less than n. A
+// negative n is treated as zero, and a NaN n spends nothing.
+func (q *Quota) SpendUpTo(n float64) float64 {
+	if math.IsNaN(n) {
+		return 0
+	}
+	oldValue, newValue := q.update(func(old float64) float64 {
+		return math.Max(old-math.Max(n, 0), 0)
+	})
+	return oldValue - newValue
+}
+
+// Refill atomically adds up to n to the balance, and returns the amount
+// actually added, which is less than n when it would have exceeded the cap. A
+// negative n is treated as zero, and a NaN n adds nothing.
+func (q *Quota) Refill(n float64) float64 {
+	if math.IsNaN(n) {
+		return 0
+	}
+	oldValue, newValue := q.update(func(old float64) float64 {
+		return math.Min(old+math.Max(n, 0), q.capacity)
+	})
+	return newValue - oldValue
+}
+
+// Cap returns the maximum balance.
+func (q *Quota) Cap() float64 {
+	return q.capacity
+}
+
+// Load atomically loads the current balance.
+func (q *Quota) Load() float64 {
+	return q.af.Load()
+}
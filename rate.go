@@ -0,0 +1,56 @@
+package atomic
+
+import (
+	"sync"
+	"time"
+)
+
+// clockOrDefault returns now, or time.Now when now is nil.
+func clockOrDefault(now func() time.Time) func() time.Time {
+	if now == nil {
+		return time.Now
+	}
+	return now
+}
+
+// RateCounter is a concurrency-safe counter of events that reports the rate at
+// which they occur.
+type RateCounter struct {
+	count atomicFloatCAS
+	now   func() time.Time
+	l     sync.Mutex
+	last  time.Time // when Rate was last called, or the counter was created
+}
+
+// NewRateCounter returns a new RateCounter that reads the current time by
+// calling now, or time.Now when now is nil.
+func NewRateCounter(now func() time.Time) *RateCounter {
+	r := &RateCounter{now: clockOrDefault(now)}
+	r.last = r.now()
+	return r
+}
+
+// Inc atomically records a single event.
+func (r *RateCounter) Inc() {
+	r.count.Inc()
+}
+
+// Add atomically records n events.
+func (r *RateCounter) Add(n float64) {
+	r.count.Add(n)
+}
+
+// Rate returns the number of events per second recorded since the previous
+// call to Rate, or since the counter was created, and starts a new interval.
+// When no time has elapsed, Rate returns 0 and the interval continues.
+func (r *RateCounter) Rate() float64 {
+	r.l.Lock()
+	defer r.l.Unlock()
+	now := r.now()
+	elapsed := now.Sub(r.last)
+	if elapsed <= 0 {
+		return 0
+	}
+	r.last = now
+	return r.count.LoadAndReset() / elapsed.Seconds()
+}
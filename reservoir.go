@@ -0,0 +1,60 @@
+package atomic
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+)
+
+// Reservoir is a concurrency-safe fixed-size uniform random sample of a stream
+// of observations, maintained with Vitter's Algorithm R, such as for estimating
+// quantiles without storing every observation. The sample is guarded by a
+// mutex, while the total number of observations may be read atomically.
+type Reservoir struct {
+	count   atomic.Uint64
+	l       sync.Mutex
+	random  *rand.Rand
+	samples []float64
+}
+
+// NewReservoir returns a new Reservoir holding at most size samples, chosen
+// using random, or a randomly seeded generator when random is nil. random is
+// only used while holding the reservoir's mutex, so it need not be safe for
+// concurrent use. NewReservoir panics when size is not positive.
+func NewReservoir(size int, random *rand.Rand) *Reservoir {
+	if size <= 0 {
+		panic(fmt.Sprintf("atomic: NewReservoir size must be positive: %d", size))
+	}
+	if random == nil {
+		random = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return &Reservoir{random: random, samples: make([]float64, 0, size)}
+}
+
+// Observe includes x in the stream, so that every observation has an equal
+// probability of being in the sample.
+func (r *Reservoir) Observe(x float64) {
+	r.l.Lock()
+	n := r.count.Add(1)
+	if len(r.samples) < cap(r.samples) {
+		r.samples = append(r.samples, x)
+	} else if i := r.random.Uint64N(n); i < uint64(len(r.samples)) {
+		r.samples[i] = x
+	}
+	r.l.Unlock()
+}
+
+// Count atomically loads the total number of observations.
+func (r *Reservoir) Count() uint64 {
+	return r.count.Load()
+}
+
+// Samples returns a copy of the current sample, which holds every observation
+// until the reservoir is full.
+func (r *Reservoir) Samples() []float64 {
+	r.l.Lock()
+	samples := append([]float64(nil), r.samples...)
+	r.l.Unlock()
+	return samples
+}
@@ -0,0 +1,46 @@
+package atomic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FmtScanner returns a fmt.Scanner that parses a single floating point token
+// and atomically stores it into af, so that af may be populated directly by
+// fmt.Sscan, fmt.Fscanf, and friends:
+//
+//	fmt.Sscanf(line, "total=%g", atomic.FmtScanner(af))
+//
+// The atomic float types cannot implement fmt.Scanner themselves, because
+// their Scan method implements sql.Scanner.
+func FmtScanner(af AtomicFloat) fmt.Scanner {
+	return fmtScanner{af}
+}
+
+type fmtScanner struct{ af AtomicFloat }
+
+// isFloatRune returns true for the runes that may appear in the textual
+// representation of a floating point number, including hexadecimal mantissas,
+// infinities, and NaN.
+func isFloatRune(r rune) bool {
+	return strings.ContainsRune("+-._0123456789aAbBcCdDeEfFiInNpPtTxXyY", r)
+}
+
+// Scan implements fmt.Scanner for the floating point verbs, and for %v.
+func (s fmtScanner) Scan(state fmt.ScanState, verb rune) error {
+	switch verb {
+	case 'b', 'e', 'E', 'f', 'F', 'g', 'G', 'x', 'X', 'v':
+	default:
+		return fmt.Errorf("atomic: bad verb '%%%c' for atomic float", verb)
+	}
+	tok, err := state.Token(true, isFloatRune)
+	if err != nil {
+		return err
+	}
+	f, err := parseTextFloat(tok)
+	if err != nil {
+		return err
+	}
+	s.af.Store(f)
+	return nil
+}
@@ -0,0 +1,125 @@
+package atomic
+
+import (
+	"math"
+	"runtime"
+	"sync/atomic"
+)
+
+// seqlock is a sequence number guarding a value that readers must observe as a
+// whole, such as one spanning several words, which no single atomic
+// instruction can read or write together. A writer claims the sequence number
+// by making it odd, writes, then makes it even again; readers retry until they
+// read without the sequence number changing or being odd. Readers therefore
+// never observe part of one write paired with part of another, and never block
+// writers, but writers exclude one another. The zero value is unlocked, at
+// sequence number zero.
+//
+//	s.lock()
+//	// write
+//	s.unlock()
+//
+//	for {
+//		seq := s.beginRead()
+//		// read
+//		if s.validRead(seq) {
+//			break
+//		}
+//	}
+type seqlock struct {
+	seq atomic.Uint64 // odd while a write is in progress
+}
+
+// lock waits for no other write to be in progress, then claims the sequence
+// number for the calling writer.
+func (s *seqlock) lock() {
+	for {
+		if seq := s.seq.Load(); seq&1 == 0 && s.seq.CompareAndSwap(seq, seq+1) {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+// tryLock claims the sequence number for the calling writer when it is seq,
+// which must be even, and returns true, or returns false when it is not.
+func (s *seqlock) tryLock(seq uint64) bool {
+	return s.seq.CompareAndSwap(seq, seq+1)
+}
+
+// unlock releases the sequence number, publishing the write, and returns the
+// new sequence number.
+func (s *seqlock) unlock() uint64 {
+	return s.seq.Add(1)
+}
+
+// beginRead waits for no write to be in progress, and returns the sequence
+// number to pass to validRead once the read is complete.
+func (s *seqlock) beginRead() uint64 {
+	for {
+		if seq := s.seq.Load(); seq&1 == 0 {
+			return seq
+		}
+		runtime.Gosched() // a write is in progress
+	}
+}
+
+// validRead returns true when no write took place since beginRead returned seq,
+// in which case the read observed a single write, or false when the read must
+// be retried.
+func (s *seqlock) validRead(seq uint64) bool {
+	return s.seq.Load() == seq
+}
+
+// atomicFloatSeqlock is an atomic float for read-mostly workloads, guarded by a
+// seqlock, so that readers never perform an atomic read-modify-write, and
+// never block writers, while writers exclude one another. Add is therefore a
+// plain load and store under the write claim rather than a compare-and-swap
+// loop that contending writers would repeat.
+type atomicFloatSeqlock struct {
+	sl  seqlock
+	u64 atomic.Uint64
+}
+
+func NewAtomicFloatSeqlock(initial float64) *atomicFloatSeqlock {
+	a := new(atomicFloatSeqlock)
+	a.u64.Store(math.Float64bits(initial))
+	return a
+}
+
+// Add attempts to add delta to the value stored in the atomic float and return
+// the new value.
+func (a *atomicFloatSeqlock) Add(delta float64) float64 {
+	a.sl.lock()
+	new := math.Float64frombits(a.u64.Load()) + delta
+	a.u64.Store(math.Float64bits(new))
+	a.sl.unlock()
+	return new
+}
+
+// Load atomically loads the current atomic float value, retrying while a write
+// is in progress or completes during the read.
+func (a *atomicFloatSeqlock) Load() float64 {
+	for {
+		seq := a.sl.beginRead()
+		f := math.Float64frombits(a.u64.Load())
+		if a.sl.validRead(seq) {
+			return f
+		}
+	}
+}
+
+// Store atomically stores new into the atomic float.
+func (a *atomicFloatSeqlock) Store(new float64) {
+	a.sl.lock()
+	a.u64.Store(math.Float64bits(new))
+	a.sl.unlock()
+}
+
+// Swap atomically stores new and returns the previous value.
+func (a *atomicFloatSeqlock) Swap(new float64) float64 {
+	a.sl.lock()
+	old := math.Float64frombits(a.u64.Swap(math.Float64bits(new)))
+	a.sl.unlock()
+	return old
+}
@@ -0,0 +1,140 @@
+package atomic
+
+import (
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// cacheLineSize is the assumed size of a CPU cache line, used to keep
+// independently updated values from sharing a cache line.
+const cacheLineSize = 64
+
+// shard is a single uint64 float value padded to occupy its own cache line.
+type shard struct {
+	u64 uint64
+	_   [cacheLineSize - 8]byte
+}
+
+// atomicFloatSharded is an atomic float that spreads Add operations across
+// several shards, one per logical processor, each on its own cache line. This
+// reduces contention between concurrent adders at the expense of Load, which
+// must fold all of the shards together.
+//
+// Because the shards are updated independently, operations that observe the
+// entire value, including the value returned by Add, are not linearizable with
+// respect to concurrent adds: they reflect every add that completed before
+// they started, and some subset of the adds that are in progress. Store, Swap,
+// and Reset replace the value by folding and zeroing every shard, so they
+// exclude one another with a mutex, which adders and loaders never take.
+type atomicFloatSharded struct {
+	shards []shard
+	mask   uint32
+	l      sync.Mutex // held by Store, Swap, and Reset
+}
+
+// shardHint is the index of the shard that an adder last added to without
+// contention, before it is masked to the number of shards.
+type shardHint struct{ i uint32 }
+
+// shardHints caches hints per logical processor, since sync.Pool keeps a
+// local cache for each, so successive adds running on the same processor
+// usually reuse its hint, and so the same shard, without any goroutine-local
+// storage, which Go does not provide. A hint is only ever used by one adder at
+// a time.
+var shardHints = sync.Pool{New: func() any { return &shardHint{i: rand.Uint32()} }}
+
+func NewAtomicFloatSharded(initial float64) *atomicFloatSharded {
+	n := 1
+	for n < runtime.GOMAXPROCS(0) {
+		n <<= 1
+	}
+	a := &atomicFloatSharded{shards: make([]shard, n), mask: uint32(n - 1)}
+	a.shards[0].u64 = math.Float64bits(initial)
+	return a
+}
+
+// addShard adds delta to the shard at index i, and returns false without
+// adding it when another adder changed the shard first.
+func (a *atomicFloatSharded) addShard(i uint32, delta float64) bool {
+	u64 := &a.shards[i].u64
+	oldBits := atomic.LoadUint64(u64)
+	newBits := math.Float64bits(math.Float64frombits(oldBits) + delta)
+	return atomic.CompareAndSwapUint64(u64, oldBits, newBits)
+}
+
+// add adds delta to the shard indicated by the calling processor's hint. When
+// another adder contends for that shard, the hint moves to a randomly chosen
+// shard, so that adders running concurrently settle on different shards.
+func (a *atomicFloatSharded) add(delta float64) {
+	h := shardHints.Get().(*shardHint)
+	for !a.addShard(h.i&a.mask, delta) {
+		h.i = rand.Uint32()
+	}
+	shardHints.Put(h)
+}
+
+// Add attempts to add delta to the value stored in the atomic float and return
+// the new value. The addition is applied to the shard the calling processor
+// last used without contention, so that concurrent adders rarely contend for
+// the same cache line, but the returned value is obtained by folding all of
+// the shards, which reads every one of them. Callers that do not need the new
+// value should use AddRelaxed instead.
+func (a *atomicFloatSharded) Add(delta float64) float64 {
+	a.add(delta)
+	return a.Load()
+}
+
+// AddRelaxed adds delta to the atomic float without returning a value, for
+// monotonic statistics counters that only need the total to be eventually
+// correct. Unlike Add, it touches only a single shard, and never folds the
+// others, so concurrent adders rarely share a cache line.
+//
+// The trade-off is ordering: whereas atomicFloatCAS.Add is linearizable, a
+// Load concurrent with relaxed adds folds the shards one at a time, so it may
+// observe a later add without an earlier one. Once adders are quiescent, Load
+// includes every add.
+func (a *atomicFloatSharded) AddRelaxed(delta float64) {
+	a.add(delta)
+}
+
+// Load folds all of the shards together to obtain the current atomic float
+// value.
+func (a *atomicFloatSharded) Load() float64 {
+	var sum float64
+	for i := range a.shards {
+		sum += math.Float64frombits(atomic.LoadUint64(&a.shards[i].u64))
+	}
+	return sum
+}
+
+// Store stores new into the atomic float. Concurrent calls to Store, Swap, and
+// Reset take effect one at a time. Adds that are concurrent with Store are not
+// lost, but are applied either before or after it.
+func (a *atomicFloatSharded) Store(new float64) {
+	a.Swap(new)
+}
+
+// Swap stores new and returns the previous value, by zeroing each shard in
+// turn and folding the values they held. Concurrent calls to Store, Swap, and
+// Reset take effect one at a time. Adds that are concurrent with Swap are not
+// lost, but are applied either before or after it.
+func (a *atomicFloatSharded) Swap(new float64) float64 {
+	a.l.Lock()
+	var old float64
+	for i := range a.shards {
+		old += math.Float64frombits(atomic.SwapUint64(&a.shards[i].u64, 0))
+	}
+	for !a.addShard(0, new) {
+		// An adder changed the shard first, so retry.
+	}
+	a.l.Unlock()
+	return old
+}
+
+// Reset stores 0 into the atomic float.
+func (a *atomicFloatSharded) Reset() {
+	a.Swap(0)
+}
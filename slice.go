@@ -0,0 +1,109 @@
+package atomic
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Slice is a fixed-length sequence of atomic floats, such as per-shard
+// accumulators. Each element is on its own cache line, so concurrent writers
+// of neighboring indices do not contend with one another.
+type Slice struct {
+	elems []PaddedAtomicFloatCAS
+}
+
+// NewSlice returns a new Slice of n elements, each initialized to 0. It panics
+// when n is negative.
+func NewSlice(n int) *Slice {
+	if n < 0 {
+		panic(fmt.Sprintf("atomic: NewSlice length must not be negative: %d", n))
+	}
+	return &Slice{elems: make([]PaddedAtomicFloatCAS, n)}
+}
+
+// elem returns the element at index i, panicking like a native slice when i
+// is out of range.
+func (s *Slice) elem(i int) *PaddedAtomicFloatCAS {
+	if i < 0 || i >= len(s.elems) {
+		panic(fmt.Sprintf("atomic: Slice index out of range [%d] with length %d", i, len(s.elems)))
+	}
+	return &s.elems[i]
+}
+
+// Len returns the number of elements in the slice.
+func (s *Slice) Len() int { return len(s.elems) }
+
+// Add atomically adds delta to the element at index i and returns its new
+// value.
+func (s *Slice) Add(i int, delta float64) float64 { return s.elem(i).Add(delta) }
+
+// Load atomically loads the element at index i.
+func (s *Slice) Load(i int) float64 { return s.elem(i).Load() }
+
+// Store atomically stores v into the element at index i.
+func (s *Slice) Store(i int, v float64) { s.elem(i).Store(v) }
+
+// Sum returns the sum of the elements. Each element is loaded atomically, but
+// the elements are not loaded at the same instant, so concurrent writes may
+// or may not be reflected in the sum.
+func (s *Slice) Sum() float64 {
+	var sum float64
+	for i := range s.elems {
+		sum += s.elems[i].Load()
+	}
+	return sum
+}
+
+// MarshalCSV encodes the elements as a single CSV record, one field per
+// element, each loaded atomically and formatted in the shortest representation
+// that parses back to the same value, including NaN, +Inf, and -Inf. As with
+// Sum, the elements are not loaded at the same instant.
+func (s *Slice) MarshalCSV() ([]byte, error) {
+	record := make([]string, len(s.elems))
+	for i := range s.elems {
+		record[i] = strconv.FormatFloat(s.elems[i].Load(), 'g', -1, 64)
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(record); err != nil {
+		return nil, err
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// UnmarshalCSV parses b as a single CSV record with one field per element, as
+// written by MarshalCSV, and atomically stores each field into the element at
+// the same index. It returns an error without storing anything when b does not
+// hold exactly one record with Len fields, or a field is not a number.
+func (s *Slice) UnmarshalCSV(b []byte) error {
+	r := csv.NewReader(bytes.NewReader(b))
+	r.FieldsPerRecord = -1
+	record, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		record, err = nil, nil // an empty slice marshals to an empty line
+	}
+	if err != nil {
+		return fmt.Errorf("atomic: cannot unmarshal CSV into Slice: %w", err)
+	}
+	if _, err := r.Read(); !errors.Is(err, io.EOF) {
+		return errors.New("atomic: cannot unmarshal CSV into Slice: more than one record")
+	}
+	if len(record) != len(s.elems) {
+		return fmt.Errorf("atomic: cannot unmarshal CSV into Slice: %d fields for length %d", len(record), len(s.elems))
+	}
+	values := make([]float64, len(record))
+	for i, field := range record {
+		if values[i], err = parseTextFloat([]byte(field)); err != nil {
+			return err
+		}
+	}
+	for i, v := range values {
+		s.elems[i].Store(v)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// SumOfSquares is a concurrency-safe accumulator of the sum of the squares of
+// a stream of samples, such as for computing the root-mean-square of a
+// signal. It tracks only the squares of the samples, so it cannot report their
+// mean. The zero value is an empty SumOfSquares ready to use.
+//
+// The sum and count are updated independently, so an RMS that is concurrent
+// with Add may reflect the sample in one but not the other.
+type SumOfSquares struct {
+	sum   atomicFloatCAS
+	count atomic.Uint64
+}
+
+// Add atomically includes the square of x in the sum. When x is so large in
+// magnitude that its square overflows, the sum becomes +Inf.
+func (s *SumOfSquares) Add(x float64) {
+	s.sum.Add(x * x)
+	s.count.Add(1)
+}
+
+// Sum returns the sum of the squares of the samples.
+func (s *SumOfSquares) Sum() float64 {
+	return s.sum.Load()
+}
+
+// Count returns the number of samples.
+func (s *SumOfSquares) Count() uint64 {
+	return s.count.Load()
+}
+
+// RMS returns the root-mean-square of the samples, or NaN when there are none,
+// or +Inf when the sum of squares has overflowed.
+func (s *SumOfSquares) RMS() float64 {
+	return math.Sqrt(s.sum.Load() / float64(s.count.Load()))
+}
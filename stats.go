@@ -0,0 +1,116 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// welford is an immutable snapshot of the running count, mean, and sum of
+// squared differences from the mean maintained by Welford's algorithm.
+type welford struct {
+	count uint64
+	mean  float64
+	m2    float64
+}
+
+// add returns the result of observing x after the observations in w.
+func (w welford) add(x float64) welford {
+	w.count++
+	delta := x - w.mean
+	w.mean += delta / float64(w.count)
+	w.m2 += delta * (x - w.mean)
+	return w
+}
+
+// merge returns the result of combining the observations in w with those in
+// o, using the parallel variance algorithm of Chan et al.
+func (w welford) merge(o welford) welford {
+	if o.count == 0 {
+		return w
+	}
+	if w.count == 0 {
+		return o
+	}
+	count := w.count + o.count
+	delta := o.mean - w.mean
+	return welford{
+		count: count,
+		mean:  w.mean + delta*float64(o.count)/float64(count),
+		m2:    w.m2 + o.m2 + delta*delta*float64(w.count)*float64(o.count)/float64(count),
+	}
+}
+
+// Stats is a concurrency-safe accumulator of the running mean and variance of
+// a stream of observations, using Welford's numerically stable algorithm. The
+// count, mean, and variance are updated together by swapping a pointer to a
+// freshly allocated snapshot, so readers always observe a consistent set, and
+// every Add allocates. The zero value is an empty Stats ready to use.
+type Stats struct {
+	p atomic.Pointer[welford]
+}
+
+// load returns the current snapshot.
+func (s *Stats) load() welford {
+	if w := s.p.Load(); w != nil {
+		return *w
+	}
+	return welford{}
+}
+
+// update atomically replaces the current snapshot with the result of calling
+// fn with it.
+func (s *Stats) update(fn func(welford) welford) {
+	for {
+		old := s.p.Load()
+		var w welford
+		if old != nil {
+			w = *old
+		}
+		w = fn(w)
+		if s.p.CompareAndSwap(old, &w) {
+			return
+		}
+	}
+}
+
+// Add atomically includes the observation x in the statistics.
+func (s *Stats) Add(x float64) {
+	s.update(func(w welford) welford { return w.add(x) })
+}
+
+// Merge atomically includes all of the observations of other in the
+// statistics, as if they had been added to s directly. other is not modified.
+func (s *Stats) Merge(other *Stats) {
+	o := other.load()
+	s.update(func(w welford) welford { return w.merge(o) })
+}
+
+// Count returns the number of observations.
+func (s *Stats) Count() uint64 {
+	return s.load().count
+}
+
+// Mean returns the mean of the observations, or NaN when there are none.
+func (s *Stats) Mean() float64 {
+	w := s.load()
+	if w.count == 0 {
+		return math.NaN()
+	}
+	return w.mean
+}
+
+// Variance returns the sample variance of the observations, or NaN when there
+// are fewer than two.
+func (s *Stats) Variance() float64 {
+	w := s.load()
+	if w.count < 2 {
+		return math.NaN()
+	}
+	return w.m2 / float64(w.count-1)
+}
+
+// StdDev returns the sample standard deviation of the observations, or NaN when
+// there are fewer than two.
+func (s *Stats) StdDev() float64 {
+	return math.Sqrt(s.Variance())
+}
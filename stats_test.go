@@ -0,0 +1,683 @@
+package atomic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// closeTo returns true when got and want differ by no more than a relative
+// tolerance of 1e-12.
+func closeTo(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-12*math.Max(math.Abs(got), math.Abs(want))
+}
+
+// sample returns the deterministic jth observation of shard i.
+func sample(i, j int) float64 {
+	return 1000 + float64((i*7919+j*104729)%1000)/10
+}
+
+func TestStats(t *testing.T) {
+	const shards, observations = 10, 1000
+
+	// Two-pass reference over the entire stream.
+	var sum float64
+	for i := 0; i < shards; i++ {
+		for j := 0; j < observations; j++ {
+			sum += sample(i, j)
+		}
+	}
+	wantMean := sum / (shards * observations)
+	var ss float64
+	for i := 0; i < shards; i++ {
+		for j := 0; j < observations; j++ {
+			d := sample(i, j) - wantMean
+			ss += d * d
+		}
+	}
+	wantVariance := ss / (shards*observations - 1)
+
+	check := func(t *testing.T, s *Stats) {
+		t.Helper()
+		if got, want := s.Count(), uint64(shards*observations); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := s.Mean(), wantMean; !closeTo(got, want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := s.Variance(), wantVariance; !closeTo(got, want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := s.StdDev(), math.Sqrt(wantVariance); !closeTo(got, want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	}
+
+	t.Run("concurrent", func(t *testing.T) {
+		var s Stats
+		parallel(shards, func(i int) {
+			for j := 0; j < observations; j++ {
+				s.Add(sample(i, j))
+			}
+		})
+		check(t, &s)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		parts := make([]Stats, shards)
+		parallel(shards, func(i int) {
+			for j := 0; j < observations; j++ {
+				parts[i].Add(sample(i, j))
+			}
+		})
+		var s Stats
+		parallel(shards, func(i int) {
+			s.Merge(&parts[i])
+		})
+		check(t, &s)
+		s.Merge(new(Stats)) // merging nothing changes nothing
+		check(t, &s)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		var s Stats
+		if got := s.Mean(); !math.IsNaN(got) {
+			t.Errorf("GOT: %v; WANT: NaN", got)
+		}
+		s.Add(3)
+		if got, want := s.Mean(), 3.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got := s.Variance(); !math.IsNaN(got) {
+			t.Errorf("GOT: %v; WANT: NaN", got)
+		}
+	})
+}
+
+func TestSummary(t *testing.T) {
+	const shards, observations = 10, 1000
+
+	wantMin, wantMax := math.Inf(1), math.Inf(-1)
+	var wantSum float64
+	for i := 0; i < shards; i++ {
+		for j := 0; j < observations; j++ {
+			x := sample(i, j)
+			wantMin = math.Min(wantMin, x)
+			wantMax = math.Max(wantMax, x)
+			wantSum += x
+		}
+	}
+
+	s := NewSummary()
+	if got := s.Mean(); !math.IsNaN(got) {
+		t.Errorf("GOT: %v; WANT: NaN", got)
+	}
+	parallel(shards, func(i int) {
+		for j := 0; j < observations; j++ {
+			s.Observe(sample(i, j))
+		}
+	})
+	if got, want := s.Min(), wantMin; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := s.Max(), wantMax; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := s.Sum(), wantSum; !closeTo(got, want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := s.Count(), uint64(shards*observations); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := s.Mean(), wantSum/(shards*observations); !closeTo(got, want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	t.Run("first", func(t *testing.T) {
+		s := NewSummary()
+		s.Observe(-7)
+		if s.Min() != -7 || s.Max() != -7 {
+			t.Errorf("GOT: [%v, %v]; WANT: [-7, -7]", s.Min(), s.Max())
+		}
+	})
+}
+
+func TestEMA(t *testing.T) {
+	t.Run("seed", func(t *testing.T) {
+		e := NewEMA(0.5)
+		if got := e.Load(); !math.IsNaN(got) {
+			t.Errorf("GOT: %v; WANT: NaN", got)
+		}
+		if got, want := e.Add(10), 10.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := e.Add(20), 15.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("serial", func(t *testing.T) {
+		const alpha = 0.1
+		e := NewEMA(alpha)
+		want := sample(0, 0)
+		e.Add(want)
+		for j := 1; j < 1000; j++ {
+			x := sample(0, j)
+			want = alpha*x + (1-alpha)*want
+			if got := e.Add(x); got != want {
+				t.Fatalf("GOT: %v; WANT: %v", got, want)
+			}
+		}
+	})
+
+	t.Run("convergence", func(t *testing.T) {
+		// Concurrent samples of a constant converge to that constant,
+		// regardless of their interleaving.
+		e := NewEMA(0.01)
+		e.Add(0)
+		parallel(10, func(int) {
+			for i := 0; i < 1000; i++ {
+				e.Add(42)
+			}
+		})
+		if got, want := e.Load(), 42.0; math.Abs(got-want) > 1e-9 {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("alpha", func(t *testing.T) {
+		for _, alpha := range []float64{0, -0.5, 1.5, math.NaN()} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%v: GOT: no panic; WANT: panic", alpha)
+					}
+				}()
+				NewEMA(alpha)
+			}()
+		}
+		NewEMA(1) // does not panic
+	})
+}
+
+func TestMovingAverage(t *testing.T) {
+	t.Run("partial", func(t *testing.T) {
+		m := NewMovingAverage(10)
+		if got := m.Average(); !math.IsNaN(got) {
+			t.Errorf("GOT: %v; WANT: NaN", got)
+		}
+		for i, want := range []float64{1, 1.5, 2, 2.5} {
+			if got := m.Add(float64(i + 1)); got != want {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		}
+		if got, want := m.Average(), 2.5; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("wraparound", func(t *testing.T) {
+		const window = 3
+		m := NewMovingAverage(window)
+		for i := 1; i <= 20; i++ {
+			got := m.Add(float64(i))
+			lo := math.Max(1, float64(i-window+1))
+			if want := (lo + float64(i)) / 2; got != want {
+				t.Errorf("%d: GOT: %v; WANT: %v", i, got, want)
+			}
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		m := NewMovingAverage(50)
+		parallel(10, func(int) {
+			for i := 0; i < 1000; i++ {
+				m.Add(4)
+				if got, want := m.Average(), 4.0; got != want {
+					t.Errorf("GOT: %v; WANT: %v", got, want)
+				}
+			}
+		})
+	})
+}
+
+func TestMedianWindow(t *testing.T) {
+	t.Run("warmup", func(t *testing.T) {
+		m := NewMedianWindow(5)
+		if got := m.Median(); !math.IsNaN(got) {
+			t.Errorf("GOT: %v; WANT: NaN", got)
+		}
+		for i, c := range []struct{ x, want float64 }{
+			{3, 3},
+			{1, 2},
+			{100, 3},
+			{4, 3.5},
+			{1, 3},
+			{9, 4}, // evicts 3
+			{5, 5}, // evicts 1
+		} {
+			if got := m.Observe(c.x); got != c.want {
+				t.Errorf("%d: GOT: %v; WANT: %v", i, got, c.want)
+			}
+		}
+		if got, want := m.Median(), 5.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	// Compare against sorting a copy of the window, for odd and even
+	// window sizes, including samples with many duplicates.
+	for _, n := range []int{1, 2, 3, 4, 7, 10} {
+		t.Run(fmt.Sprintf("window-%d", n), func(t *testing.T) {
+			random := rand.New(rand.NewPCG(1, uint64(n)))
+			m := NewMedianWindow(n)
+			var samples []float64
+			for i := 0; i < 200; i++ {
+				x := float64(random.IntN(20)) - 10
+				samples = append(samples, x)
+				window := slices.Clone(samples[max(0, len(samples)-n):])
+				slices.Sort(window)
+				want := window[len(window)/2]
+				if len(window)%2 == 0 {
+					want = (window[len(window)/2-1] + want) / 2
+				}
+				if got := m.Observe(x); got != want {
+					t.Fatalf("%d: GOT: %v; WANT: %v", i, got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("concurrent", func(t *testing.T) {
+		m := NewMedianWindow(51)
+		parallel(10, func(i int) {
+			for j := 0; j < 1000; j++ {
+				m.Observe(float64(i % 2)) // equally many 0 and 1 samples
+				if got := m.Median(); got < 0 || got > 1 {
+					t.Errorf("GOT: %v; WANT: within [0, 1]", got)
+					return
+				}
+			}
+		})
+	})
+}
+
+// lockedRandom returns a deterministic source of random numbers in [0, 1),
+// seeded with seed, which is safe for concurrent use.
+func lockedRandom(seed uint64) func() float64 {
+	var l sync.Mutex
+	r := rand.New(rand.NewPCG(seed, seed))
+	return func() float64 {
+		l.Lock()
+		defer l.Unlock()
+		return r.Float64()
+	}
+}
+
+func TestApproxCounter(t *testing.T) {
+	t.Run("first", func(t *testing.T) {
+		// The first event is always counted exactly.
+		a := NewApproxCounter(lockedRandom(1))
+		if got, want := a.Estimate(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		a.Inc()
+		if got, want := a.Estimate(), 1.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("unbiased", func(t *testing.T) {
+		// The standard deviation of a single estimate of n events is about
+		// n/√2, so the mean of runs estimates has a standard deviation of
+		// about n/√(2*runs). Allow five times that.
+		const runs, events = 1000, 1000
+		random := lockedRandom(42)
+		var sum float64
+		for i := 0; i < runs; i++ {
+			a := NewApproxCounter(random)
+			for j := 0; j < events; j++ {
+				a.Inc()
+			}
+			sum += a.Estimate()
+		}
+		mean := sum / runs
+		if tolerance := 5 * events / math.Sqrt(2*runs); math.Abs(mean-events) > tolerance {
+			t.Errorf("GOT: %v; WANT: %v ± %v", mean, events, tolerance)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		const runs, goroutines, events = 200, 10, 100
+		random := lockedRandom(7)
+		var sum float64
+		for i := 0; i < runs; i++ {
+			a := NewApproxCounter(random)
+			parallel(goroutines, func(int) {
+				for j := 0; j < events; j++ {
+					a.Inc()
+				}
+			})
+			sum += a.Estimate()
+		}
+		const n = goroutines * events
+		mean := sum / runs
+		if tolerance := 5 * n / math.Sqrt(2*runs); math.Abs(mean-n) > tolerance {
+			t.Errorf("GOT: %v; WANT: %v ± %v", mean, n, tolerance)
+		}
+	})
+}
+
+func TestWeightedMean(t *testing.T) {
+	const shards, observations = 10, 1000
+
+	weight := func(i, j int) float64 { return float64((i + j) % 4) }
+	var sum, total float64
+	for i := 0; i < shards; i++ {
+		for j := 0; j < observations; j++ {
+			sum += sample(i, j) * weight(i, j)
+			total += weight(i, j)
+		}
+	}
+
+	var w WeightedMean
+	if got := w.Mean(); !math.IsNaN(got) {
+		t.Errorf("GOT: %v; WANT: NaN", got)
+	}
+	w.Add(123, 0)
+	if got := w.Mean(); !math.IsNaN(got) {
+		t.Errorf("GOT: %v; WANT: NaN", got)
+	}
+
+	parallel(shards, func(i int) {
+		for j := 0; j < observations; j++ {
+			if err := w.Add(sample(i, j), weight(i, j)); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+	if got, want := w.Mean(), sum/total; !closeTo(got, want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := w.TotalWeight(), total; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	for _, weight := range []float64{-1, math.NaN()} {
+		if got, want := w.Add(1, weight), ErrNegativeWeight; !errors.Is(got, want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	}
+	if got, want := w.TotalWeight(), total; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestGeometricAndHarmonicMean(t *testing.T) {
+	const shards, observations = 10, 1000
+
+	var sumLogs, sumReciprocals float64
+	for i := 0; i < shards; i++ {
+		for j := 0; j < observations; j++ {
+			sumLogs += math.Log(sample(i, j))
+			sumReciprocals += 1 / sample(i, j)
+		}
+	}
+
+	var g GeometricMean
+	var h HarmonicMean
+	if got := g.Mean(); !math.IsNaN(got) {
+		t.Errorf("GOT: %v; WANT: NaN", got)
+	}
+	if got := h.Mean(); !math.IsNaN(got) {
+		t.Errorf("GOT: %v; WANT: NaN", got)
+	}
+	parallel(shards, func(i int) {
+		for j := 0; j < observations; j++ {
+			if err := g.Add(sample(i, j)); err != nil {
+				t.Error(err)
+			}
+			if err := h.Add(sample(i, j)); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+	if got, want := g.Mean(), math.Exp(sumLogs/(shards*observations)); !closeTo(got, want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := h.Mean(), (shards*observations)/sumReciprocals; !closeTo(got, want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	for _, x := range []float64{0, -1, math.NaN()} {
+		if got, want := g.Add(x), ErrNotPositive; !errors.Is(got, want) {
+			t.Errorf("%v: GOT: %v; WANT: %v", x, got, want)
+		}
+	}
+	if got, want := h.Add(0), ErrZero; !errors.Is(got, want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := h.Add(math.NaN()), ErrNaN; !errors.Is(got, want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := h.Mean(), (shards*observations)/sumReciprocals; !closeTo(got, want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := g.Count(), float64(shards*observations); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := h.Count(), float64(shards*observations); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestProduct(t *testing.T) {
+	t.Run("underflow", func(t *testing.T) {
+		const factors = 10000
+		var p Product
+		naive := 1.0
+		parallel(10, func(int) {
+			for i := 0; i < factors/10; i++ {
+				p.Mul(0.5)
+			}
+		})
+		for i := 0; i < factors; i++ {
+			naive *= 0.5
+		}
+		if naive != 0 {
+			t.Fatalf("GOT: %v; WANT: naive product to underflow", naive)
+		}
+		// Value underflows just as the naive product does, but LogAbs
+		// remains representable.
+		if got, want := p.Value(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := p.LogAbs(), factors*math.Log(0.5); !closeTo(got, want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		// Recovering from the underflow, unlike the naive product.
+		parallel(10, func(int) {
+			for i := 0; i < factors/10; i++ {
+				p.Mul(-2)
+			}
+		})
+		if got := p.LogAbs(); math.Abs(got) > 1e-9 {
+			t.Errorf("GOT: %v; WANT: 0", got)
+		}
+		if got, want := p.Value(), 1.0; !closeTo(got, want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("sign", func(t *testing.T) {
+		var p Product
+		if got, want := p.Value(), 1.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		p.Mul(-4)
+		if got, want := p.Value(), -4.0; !closeTo(got, want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		p.Mul(-0.5)
+		if got, want := p.Value(), 2.0; !closeTo(got, want) {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("zero", func(t *testing.T) {
+		var p Product
+		p.Mul(3)
+		p.Mul(0)
+		p.Mul(1e300)
+		if got, want := p.Value(), 0.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := p.LogAbs(), math.Inf(-1); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestSumOfSquares(t *testing.T) {
+	const shards, observations = 10, 1000
+
+	var sum float64
+	for i := 0; i < shards; i++ {
+		for j := 0; j < observations; j++ {
+			x := sample(i, j) - 1050 // both signs
+			sum += x * x
+		}
+	}
+
+	var s SumOfSquares
+	if got := s.RMS(); !math.IsNaN(got) {
+		t.Errorf("GOT: %v; WANT: NaN", got)
+	}
+	parallel(shards, func(i int) {
+		for j := 0; j < observations; j++ {
+			s.Add(sample(i, j) - 1050)
+		}
+	})
+	if got, want := s.RMS(), math.Sqrt(sum/(shards*observations)); !closeTo(got, want) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := s.Count(), uint64(shards*observations); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	s.Add(-1e200)
+	if got, want := s.RMS(), math.Inf(1); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram(1, 2.5, 10)
+	parallel(100, func(int) {
+		for _, x := range []float64{-5, 1, 1.5, 2.5, 3, 10, 11, math.Inf(1)} {
+			h.Observe(x)
+		}
+	})
+	s := h.Snapshot()
+	want := []float64{200, 200, 200, 200}
+	for i := range want {
+		if got, want := s.Counts[i], want[i]; got != want {
+			t.Errorf("%d: GOT: %v; WANT: %v", i, got, want)
+		}
+	}
+	if got, want := s.Count, 800.0; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+	if got, want := s.Sum, math.Inf(1); got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	t.Run("concurrent-snapshot", func(t *testing.T) {
+		h := NewHistogram(0)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 1000; i++ {
+				s := h.Snapshot()
+				// The total may only run ahead of the buckets.
+				if total := s.Counts[0] + s.Counts[1]; s.Count < total {
+					t.Errorf("GOT: %v; WANT: >= %v", s.Count, total)
+				}
+			}
+		}()
+		parallel(10, func(i int) {
+			for j := 0; j < 1000; j++ {
+				h.Observe(float64(i - 5))
+			}
+		})
+		<-done
+	})
+
+	t.Run("bounds", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("GOT: no panic; WANT: panic")
+			}
+		}()
+		NewHistogram(1, 1)
+	})
+}
+
+func TestReservoir(t *testing.T) {
+	t.Run("partial", func(t *testing.T) {
+		r := NewReservoir(5, nil)
+		r.Observe(1)
+		r.Observe(2)
+		if got, want := len(r.Samples()), 2; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("uniform", func(t *testing.T) {
+		// Each of the stream elements has an equal probability, size/stream,
+		// of being sampled, and is never sampled twice.
+		const trials, stream, size = 10000, 10, 3
+		random := rand.New(rand.NewPCG(1, 2))
+		var frequency [stream]float64
+		for i := 0; i < trials; i++ {
+			r := NewReservoir(size, random)
+			for x := 0; x < stream; x++ {
+				r.Observe(float64(x))
+			}
+			seen := make(map[float64]bool)
+			for _, x := range r.Samples() {
+				if seen[x] {
+					t.Fatalf("GOT: duplicate sample %v; WANT: none", x)
+				}
+				seen[x] = true
+				frequency[int(x)]++
+			}
+		}
+		const p = float64(size) / stream
+		tolerance := 5 * math.Sqrt(trials*p*(1-p))
+		for x, got := range frequency {
+			if want := trials * p; math.Abs(got-want) > tolerance {
+				t.Errorf("%d: GOT: %v; WANT: %v ± %v", x, got, want, tolerance)
+			}
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		r := NewReservoir(100, rand.New(rand.NewPCG(3, 4)))
+		parallel(10, func(i int) {
+			for j := 0; j < 1000; j++ {
+				r.Observe(float64(i*1000 + j))
+			}
+		})
+		if got, want := r.Count(), uint64(10000); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := len(r.Samples()), 100; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package atomic
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// stressOp records one operation performed by TestLinearizability, and the
+// value it returned.
+type stressOp struct {
+	kind   byte    // 'a' for Add, 's' for Store, 'w' for Swap
+	delta  float64 // added by Add
+	tag    uint64  // written by Store and Swap
+	result float64 // returned by Add and Swap
+}
+
+// stressTagUnit separates the tag written by the most recent Store or Swap,
+// in the high part of the value, from the sum of the adds since, in the low
+// part. Both parts remain exact integers well below 2^53.
+const stressTagUnit = 1 << 32
+
+// decodeStress splits v into the tag of the write it descends from and the sum
+// of the adds applied since that write.
+func decodeStress(v float64) (tag uint64, adds float64) {
+	tag = uint64(v / stressTagUnit)
+	return tag, v - float64(tag)*stressTagUnit
+}
+
+// TestLinearizability is a standing guard against subtle bugs in the retry
+// loops of the implementations. Many goroutines perform a random mix of Add,
+// Store, and Swap, under several GOMAXPROCS settings, and the returned values
+// are then checked against the only histories a linearizable atomic float
+// could have produced.
+//
+// Each Store and Swap writes a unique tag, and adds are small positive
+// integers, so every value identifies the write it descends from, and the sum
+// of the adds applied since. The adds that landed after each write must then
+// form a single chain, each returning the previous value plus its own delta;
+// each Swap must return exactly the sum of the adds that landed after the write
+// it replaced, since no further add can land there; no write may be replaced
+// twice; and the final value must be the last write plus every add that landed
+// after it. Run it with -race to also check the happens-before edges.
+func TestLinearizability(t *testing.T) {
+	implementations := []struct {
+		name string
+		new  func(initial float64) AtomicFloat
+	}{
+		{"cas", func(f float64) AtomicFloat { return NewAtomicFloatCAS(f) }},
+		{"cas-options", func(f float64) AtomicFloat { return NewAtomicFloatCAS(f, WithBackoff(GoschedBackoff{})) }},
+		{"cas2", func(f float64) AtomicFloat { return NewAtomicFloatCAS2(f) }},
+		{"lock", func(f float64) AtomicFloat { return NewAtomicFloatMutex(f) }},
+		{"number", func(f float64) AtomicFloat { return NewNumber(f) }},
+		{"padded", func(f float64) AtomicFloat { return NewPaddedAtomicFloatCAS(f) }},
+		{"pause", func(f float64) AtomicFloat { return NewAtomicFloatPauseCAS(f) }},
+		{"backoff", func(f float64) AtomicFloat { return NewAtomicFloatBackoffCAS(f, 4, 16) }},
+		{"ptr", func(f float64) AtomicFloat { return NewAtomicFloatPtr(f) }},
+		{"instrumented", func(f float64) AtomicFloat { return NewAtomicFloatInstrumentedCAS(f) }},
+		{"seqlock", func(f float64) AtomicFloat { return NewAtomicFloatSeqlock(f) }},
+	}
+
+	goroutines, ops := 16, 2000
+	if testing.Short() {
+		ops = 200
+	}
+
+	for _, procs := range []int{1, 2, 4, 8} {
+		t.Run("GOMAXPROCS="+strconv.Itoa(procs), func(t *testing.T) {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+			for _, impl := range implementations {
+				t.Run(impl.name, func(t *testing.T) {
+					af := impl.new(0)
+					histories := make([][]stressOp, goroutines)
+					parallel(goroutines, func(i int) {
+						random := rand.New(rand.NewPCG(uint64(procs), uint64(i)))
+						history := make([]stressOp, ops)
+						for j := range history {
+							op := &history[j]
+							switch n := random.IntN(10); {
+							case n < 8:
+								op.kind, op.delta = 'a', float64(1+random.IntN(7))
+								op.result = af.Add(op.delta)
+							case n == 8:
+								op.kind, op.tag = 's', uint64(1+i*ops+j)
+								af.Store(float64(op.tag) * stressTagUnit)
+							default:
+								op.kind, op.tag = 'w', uint64(1+i*ops+j)
+								op.result = af.Swap(float64(op.tag) * stressTagUnit)
+							}
+							if random.IntN(16) == 0 {
+								runtime.Gosched()
+							}
+						}
+						histories[i] = history
+					})
+					checkLinearizable(t, histories, af.Load())
+				})
+			}
+		})
+	}
+}
+
+// checkLinearizable checks the histories recorded by TestLinearizability, and
+// the final value, as described there.
+func checkLinearizable(t *testing.T, histories [][]stressOp, final float64) {
+	t.Helper()
+
+	type landed struct{ adds, delta float64 }
+	written := map[uint64]bool{0: true} // the initial value has tag 0
+	segments := make(map[uint64][]landed)
+	var swaps []float64
+	for _, history := range histories {
+		for _, op := range history {
+			switch op.kind {
+			case 'a':
+				tag, adds := decodeStress(op.result)
+				segments[tag] = append(segments[tag], landed{adds, op.delta})
+			case 's':
+				written[op.tag] = true
+			case 'w':
+				written[op.tag] = true
+				swaps = append(swaps, op.result)
+			}
+		}
+	}
+
+	// The adds since each write form a single chain.
+	sums := make(map[uint64]float64)
+	for tag, adds := range segments {
+		if !written[tag] {
+			t.Fatalf("add returned a value descended from tag %d, which was never written", tag)
+		}
+		sort.Slice(adds, func(i, j int) bool { return adds[i].adds < adds[j].adds })
+		var sum float64
+		for _, a := range adds {
+			if got, want := a.adds, sum+a.delta; got != want {
+				t.Fatalf("tag %d: add of %v: GOT: %v; WANT: %v", tag, a.delta, got, want)
+			}
+			sum = a.adds
+		}
+		sums[tag] = sum
+	}
+
+	// Each Swap replaces a distinct write, after all of its adds.
+	replaced := make(map[uint64]bool)
+	for _, v := range swaps {
+		tag, adds := decodeStress(v)
+		if !written[tag] {
+			t.Fatalf("swap returned a value descended from tag %d, which was never written", tag)
+		}
+		if replaced[tag] {
+			t.Fatalf("tag %d: replaced by more than one swap", tag)
+		}
+		replaced[tag] = true
+		if got, want := adds, sums[tag]; got != want {
+			t.Fatalf("tag %d: swap: GOT: %v; WANT: %v", tag, got, want)
+		}
+	}
+
+	// The final value is the last write plus the adds since.
+	tag, adds := decodeStress(final)
+	if !written[tag] || replaced[tag] {
+		t.Fatalf("final value descends from tag %d, which was not the last write", tag)
+	}
+	if got, want := adds, sums[tag]; got != want {
+		t.Fatalf("final: GOT: %v; WANT: %v", got, want)
+	}
+}
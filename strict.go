@@ -0,0 +1,79 @@
+package atomic
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+)
+
+// strictAtomicFloat is an atomic float that never holds NaN or ±Inf. Store and
+// Swap reject non-finite values outright, but Add can also produce ±Inf from
+// finite operands when the sum overflows, so Add computes the sum and checks it
+// before committing it with the compare-and-swap, rather than after.
+type strictAtomicFloat struct {
+	u64    atomic.Uint64
+	panics bool
+}
+
+// NewStrictAtomicFloat returns an atomic float initialized to initial, which
+// must be finite, or else it returns ErrNotFinite. A write that would store a
+// non-finite value is rejected: when panics is false it is silently ignored,
+// leaving the stored value unchanged, and when panics is true it panics.
+func NewStrictAtomicFloat(initial float64, panics bool) (*strictAtomicFloat, error) {
+	if math.IsNaN(initial) || math.IsInf(initial, 0) {
+		return nil, fmt.Errorf("%w: %v", ErrNotFinite, initial)
+	}
+	a := &strictAtomicFloat{panics: panics}
+	a.u64.Store(math.Float64bits(initial))
+	return a, nil
+}
+
+// finite returns true when v is finite, and otherwise either returns false or
+// panics, depending on how the atomic float was constructed.
+func (a *strictAtomicFloat) finite(method string, v float64) bool {
+	if !math.IsNaN(v) && !math.IsInf(v, 0) {
+		return true
+	}
+	if a.panics {
+		panic(fmt.Sprintf("atomic: strict %s rejected non-finite value: %v", method, v))
+	}
+	return false
+}
+
+// Add attempts to add delta to the value stored in the atomic float and return
+// the new value. When the new value would not be finite, the stored value is
+// left untouched and returned.
+func (a *strictAtomicFloat) Add(delta float64) float64 {
+	for {
+		oldBits := a.u64.Load()
+		oldValue := math.Float64frombits(oldBits)
+		newValue := oldValue + delta
+		if !a.finite("Add", newValue) {
+			return oldValue
+		}
+		if a.u64.CompareAndSwap(oldBits, math.Float64bits(newValue)) {
+			return newValue
+		}
+	}
+}
+
+// Load atomically loads the current atomic float value.
+func (a *strictAtomicFloat) Load() float64 {
+	return math.Float64frombits(a.u64.Load())
+}
+
+// Store atomically stores new into the atomic float, unless it is not finite.
+func (a *strictAtomicFloat) Store(new float64) {
+	if a.finite("Store", new) {
+		a.u64.Store(math.Float64bits(new))
+	}
+}
+
+// Swap atomically stores new and returns the previous value. When new is not
+// finite, the stored value is left untouched and returned.
+func (a *strictAtomicFloat) Swap(new float64) float64 {
+	if !a.finite("Swap", new) {
+		return a.Load()
+	}
+	return math.Float64frombits(a.u64.Swap(math.Float64bits(new)))
+}
@@ -0,0 +1,58 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Summary is a concurrency-safe summary of a stream of observations, tracking
+// their minimum, maximum, sum, and count. Each field is updated by its own
+// lock-free operation, so concurrent observers never contend on a shared lock.
+// As a consequence, readers may observe an observation reflected in some fields
+// but not yet in others.
+type Summary struct {
+	min, max, sum atomicFloatCAS
+	count         atomic.Uint64
+}
+
+// NewSummary returns a new Summary without any observations.
+func NewSummary() *Summary {
+	s := new(Summary)
+	s.min.Store(math.Inf(1))
+	s.max.Store(math.Inf(-1))
+	return s
+}
+
+// Observe includes the observation x in the summary. As with math.Min and
+// math.Max, once NaN is observed, the minimum and maximum are NaN.
+func (s *Summary) Observe(x float64) {
+	s.min.Min(x)
+	s.max.Max(x)
+	s.sum.Add(x)
+	s.count.Add(1)
+}
+
+// Min returns the least observation, or +Inf when there are none.
+func (s *Summary) Min() float64 {
+	return s.min.Load()
+}
+
+// Max returns the greatest observation, or -Inf when there are none.
+func (s *Summary) Max() float64 {
+	return s.max.Load()
+}
+
+// Sum returns the sum of the observations.
+func (s *Summary) Sum() float64 {
+	return s.sum.Load()
+}
+
+// Count returns the number of observations.
+func (s *Summary) Count() uint64 {
+	return s.count.Load()
+}
+
+// Mean returns the mean of the observations, or NaN when there are none.
+func (s *Summary) Mean() float64 {
+	return s.sum.Load() / float64(s.count.Load())
+}
@@ -0,0 +1,168 @@
+package atomic
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"testing"
+)
+
+// exactSum returns the correctly rounded sum of values, computed with enough
+// precision that no bits are lost.
+func exactSum(values ...float64) float64 {
+	sum := new(big.Float).SetPrec(2048)
+	for _, v := range values {
+		sum.Add(sum, big.NewFloat(v))
+	}
+	f, _ := sum.Float64()
+	return f
+}
+
+// withinULP returns true when got is no more than n representable values away
+// from want.
+func withinULP(got, want float64, n int) bool {
+	lo, hi := want, want
+	for i := 0; i < n; i++ {
+		lo = math.Nextafter(lo, math.Inf(-1))
+		hi = math.Nextafter(hi, math.Inf(1))
+	}
+	return lo <= got && got <= hi
+}
+
+func TestKahanCAS(t *testing.T) {
+	const goroutines, adds, delta = 10, 100000, 0.1
+
+	values := make([]float64, goroutines*adds)
+	for i := range values {
+		values[i] = delta
+	}
+	want := exactSum(values...)
+
+	plain := NewAtomicFloatCAS(0)
+	kahan := NewAtomicFloatKahanCAS(0)
+	parallel(goroutines, func(int) {
+		for i := 0; i < adds; i++ {
+			plain.Add(delta)
+			kahan.Add(delta)
+		}
+	})
+
+	if got := plain.Load(); withinULP(got, want, 100) {
+		t.Errorf("plain sum unexpectedly accurate: GOT: %v; WANT: %v", got, want)
+	}
+	if got := kahan.Load(); !withinULP(got, want, 1) {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestNeumaier(t *testing.T) {
+	sequences := map[string][]float64{
+		"large-then-small": {1e100, 1, -1e100, 1},
+		"small-then-large": {1, 1e100, 1, -1e100},
+		"mixed":            {0.1, 1e20, 0.2, -1e20, 0.3, 1e-20},
+	}
+	for name, values := range sequences {
+		t.Run(name, func(t *testing.T) {
+			a := NewAtomicFloatNeumaier(0)
+			for _, v := range values {
+				a.Add(v)
+			}
+			if got, want := a.LoadExact(), exactSum(values...); !withinULP(got, want, 1) {
+				t.Errorf("GOT: %v; WANT: %v", got, want)
+			}
+		})
+	}
+
+	t.Run("concurrent", func(t *testing.T) {
+		a := NewAtomicFloatNeumaier(0)
+		parallel(100, func(int) {
+			for i := 0; i < 100; i++ {
+				a.Add(1e16)
+				a.Add(1)
+				a.Add(-1e16)
+			}
+		})
+		if got, want := a.LoadExact(), 10000.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestBigFloat(t *testing.T) {
+	tenth, _, err := big.ParseFloat("0.1", 10, 256, big.ToNearestEven)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAtomicBigFloat(0, 0)
+	var f float64
+	for i := 0; i < 1000; i++ {
+		f += 0.1
+	}
+	parallel(10, func(int) {
+		for i := 0; i < 100; i++ {
+			a.Add(tenth)
+		}
+	})
+	a.AddFloat64(0.5)
+
+	// float64 accumulates rounding error within fourteen digits, but 256
+	// bits of precision does not.
+	if got, want := strconv.FormatFloat(f+0.5, 'f', 14, 64), "100.50000000000000"; got == want {
+		t.Errorf("GOT: %v; WANT: float64 rounding error", got)
+	}
+	if got, want := a.Text('f', 14), "100.50000000000000"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+
+	// Load returns a copy that does not share storage with the accumulator.
+	v := a.Load()
+	v.SetInt64(0)
+	if got, want := a.Text('f', 1), "100.5"; got != want {
+		t.Errorf("GOT: %v; WANT: %v", got, want)
+	}
+}
+
+func TestFixedPoint(t *testing.T) {
+	t.Run("exact", func(t *testing.T) {
+		f := NewFixedPoint(2)
+		parallel(10, func(int) {
+			for i := 0; i < 100000; i++ {
+				f.Add(0.10)
+			}
+		})
+		if got, want := f.Units(), int64(10000000); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if got, want := f.Load(), 100000.0; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("round", func(t *testing.T) {
+		f := NewFixedPoint(0)
+		for _, v := range []float64{0.5, 1.5, 2.5, -0.5, -1.5} { // half to even
+			f.Add(v)
+		}
+		if got, want := f.Units(), int64(0+2+2-0-2); got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		f := NewFixedPoint(2)
+		if _, err := f.Add(5e16); err != nil {
+			t.Fatal(err)
+		}
+		before := f.Units()
+		for _, v := range []float64{5e16, 1e300, math.Inf(-1), math.NaN()} {
+			if _, err := f.Add(v); !errors.Is(err, ErrOverflow) {
+				t.Errorf("%v: GOT: %v; WANT: %v", v, err, ErrOverflow)
+			}
+		}
+		if got, want := f.Units(), before; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
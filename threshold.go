@@ -0,0 +1,94 @@
+package atomic
+
+import "math"
+
+// thresholdCallback is a function registered with atomicFloatCAS.OnThreshold.
+type thresholdCallback struct {
+	threshold float64
+	fn        func(new float64, rising bool)
+}
+
+// OnThreshold registers fn to be called whenever any method that writes the
+// atomic float, whether Add, Store, Swap, CompareAndSwap, Update, or any other,
+// changes its value from below threshold to at or above it, in which case
+// rising is true, or from at or above threshold to below it, in which case
+// rising is false. It returns a function that removes the registration. Any
+// number of thresholds may be registered.
+//
+// Crossings are detected by comparing the old and new values of each
+// successful compare-and-swap or swap, and fn is called on the goroutine whose
+// write committed the crossing, after it has committed, so concurrent writers
+// neither miss nor duplicate a crossing. While any threshold is registered,
+// Store is therefore performed as a swap. fn is passed the value that crossed.
+// Since writers call fn concurrently, calls for successive crossings may
+// overlap, or even run out of order, and fn delays the writer, so it should be
+// fast. Transitions to or from NaN never cross a threshold.
+func (a *atomicFloatCAS) OnThreshold(threshold float64, fn func(new float64, rising bool)) (cancel func()) {
+	c := &thresholdCallback{threshold: threshold, fn: fn}
+	a.updateThresholds(func(old []*thresholdCallback) []*thresholdCallback {
+		return append(old[:len(old):len(old)], c)
+	})
+	return func() {
+		a.updateThresholds(func(old []*thresholdCallback) []*thresholdCallback {
+			for i, o := range old {
+				if o == c {
+					callbacks := make([]*thresholdCallback, 0, len(old)-1)
+					return append(append(callbacks, old[:i]...), old[i+1:]...)
+				}
+			}
+			return old
+		})
+	}
+}
+
+// updateThresholds atomically replaces the registered threshold callbacks with
+// the copy returned by fn, which must not modify the slice it is passed.
+func (a *atomicFloatCAS) updateThresholds(fn func(old []*thresholdCallback) []*thresholdCallback) {
+	for {
+		oldPtr := a.thresholds.Load()
+		var old []*thresholdCallback
+		if oldPtr != nil {
+			old = *oldPtr
+		}
+		next := fn(old)
+		if a.thresholds.CompareAndSwap(oldPtr, &next) {
+			return
+		}
+	}
+}
+
+// storeBits atomically stores newBits into the atomic float, invoking the
+// callbacks whose thresholds lie between the value it replaced and the new
+// value. Unless a threshold is registered, it is a plain store.
+func (a *atomicFloatCAS) storeBits(newBits uint64) {
+	if a.thresholds.Load() == nil {
+		a.u64.Store(newBits)
+		return
+	}
+	a.swapBits(newBits)
+}
+
+// swapBits atomically stores newBits into the atomic float and returns the bits
+// it replaced, invoking the callbacks whose thresholds lie between the two
+// values.
+func (a *atomicFloatCAS) swapBits(newBits uint64) uint64 {
+	oldBits := a.u64.Swap(newBits)
+	a.crossed(math.Float64frombits(oldBits), math.Float64frombits(newBits))
+	return oldBits
+}
+
+// crossed invokes the callbacks whose thresholds lie between old and new.
+func (a *atomicFloatCAS) crossed(old, new float64) {
+	p := a.thresholds.Load()
+	if p == nil {
+		return
+	}
+	for _, c := range *p {
+		switch {
+		case old < c.threshold && c.threshold <= new:
+			c.fn(new, true)
+		case new < c.threshold && c.threshold <= old:
+			c.fn(new, false)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package atomic
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// timestampedState is an immutable snapshot of a TimestampedFloat's value and
+// the time it was written.
+type timestampedState struct {
+	value float64
+	at    time.Time
+}
+
+// TimestampedFloat is a concurrency-safe float that remembers when it was last
+// written, such as for detecting stale readings. The value and its timestamp
+// are swapped together through a pointer to a freshly allocated snapshot, so
+// readers always observe a consistent pair, and every Set allocates.
+type TimestampedFloat struct {
+	p   atomic.Pointer[timestampedState]
+	now func() time.Time
+}
+
+// NewTimestampedFloat returns a new TimestampedFloat that has never been set,
+// reading the current time by calling now, or time.Now when now is nil.
+func NewTimestampedFloat(now func() time.Time) *TimestampedFloat {
+	return &TimestampedFloat{now: clockOrDefault(now)}
+}
+
+// Set atomically stores v, stamped with the current time.
+func (t *TimestampedFloat) Set(v float64) {
+	t.p.Store(&timestampedState{value: v, at: t.now()})
+}
+
+// Load atomically loads the value and the time it was set, or 0 and the zero
+// time when it has never been set.
+func (t *TimestampedFloat) Load() (v float64, at time.Time) {
+	if s := t.p.Load(); s != nil {
+		return s.value, s.at
+	}
+	return 0, time.Time{}
+}
+
+// LoadIfFresh atomically loads the value and returns it with true when it was
+// set no more than maxAge ago, or returns 0 and false when it is older or has
+// never been set.
+func (t *TimestampedFloat) LoadIfFresh(maxAge time.Duration) (v float64, ok bool) {
+	s := t.p.Load()
+	if s == nil || t.now().Sub(s.at) > maxAge {
+		return 0, false
+	}
+	return s.value, true
+}
@@ -0,0 +1,92 @@
+package atomic
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// bucketState is an immutable snapshot of the tokens in a TokenBucket as of the
+// time they were last refilled, or of the level of a LeakyBucket as of the time
+// it last leaked.
+type bucketState struct {
+	tokens float64
+	at     time.Time
+}
+
+// TokenBucket is a lock-free token bucket rate limiter, which permits bursts of
+// up to burst tokens, refilled continuously at rate tokens per second. Tokens
+// are fractional. Refilling and deducting tokens is a single compare-and-swap
+// of a pointer to a freshly allocated snapshot of the tokens and the time they
+// were refilled, so concurrent callers can never draw more tokens than the
+// bucket holds, and every successful call allocates.
+type TokenBucket struct {
+	p     atomic.Pointer[bucketState]
+	rate  float64 // tokens per second
+	burst float64
+	now   func() time.Time
+}
+
+// NewTokenBucket returns a new, full TokenBucket holding at most burst tokens,
+// refilled at rate tokens per second, reading the current time by calling now,
+// or time.Now when now is nil. It panics when rate is negative or burst is not
+// positive.
+func NewTokenBucket(rate, burst float64, now func() time.Time) *TokenBucket {
+	if !(rate >= 0) || math.IsInf(rate, 0) {
+		panic(fmt.Sprintf("atomic: NewTokenBucket rate must be finite and not negative: %v", rate))
+	}
+	if !(burst > 0) || math.IsInf(burst, 0) {
+		panic(fmt.Sprintf("atomic: NewTokenBucket burst must be finite and positive: %v", burst))
+	}
+	b := &TokenBucket{rate: rate, burst: burst, now: clockOrDefault(now)}
+	b.p.Store(&bucketState{tokens: burst, at: b.now()})
+	return b
+}
+
+// refilled returns the tokens of s refilled from when it was last refilled
+// until now, up to the burst size. Time that appears to run backwards refills
+// nothing.
+func (b *TokenBucket) refilled(s *bucketState, now time.Time) float64 {
+	elapsed := now.Sub(s.at).Seconds()
+	if elapsed <= 0 {
+		return s.tokens
+	}
+	return math.Min(s.tokens+elapsed*b.rate, b.burst)
+}
+
+// Allow atomically takes a single token from the bucket, and returns true, or
+// returns false when the bucket holds less than one token.
+func (b *TokenBucket) Allow() bool {
+	return b.AllowN(1)
+}
+
+// AllowN atomically refills the bucket, then takes n tokens from it, and
+// returns true, or returns false, taking nothing, when the bucket holds fewer
+// than n tokens. Requests for more than burst tokens always fail, as do
+// requests for zero, negative, or NaN tokens, which would otherwise grant
+// nothing or add tokens to the bucket.
+func (b *TokenBucket) AllowN(n float64) bool {
+	if !(n > 0) {
+		return false
+	}
+	for {
+		old := b.p.Load()
+		now := b.now()
+		if now.Before(old.at) {
+			now = old.at
+		}
+		tokens := b.refilled(old, now)
+		if !(tokens >= n) {
+			return false
+		}
+		if b.p.CompareAndSwap(old, &bucketState{tokens: tokens - n, at: now}) {
+			return true
+		}
+	}
+}
+
+// Tokens returns the number of tokens the bucket holds at the current time.
+func (b *TokenBucket) Tokens() float64 {
+	return b.refilled(b.p.Load(), b.now())
+}
@@ -0,0 +1,79 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// VersionedFloat is an atomic float paired with a generation number that every
+// write increments, such as for detecting stale reads, or the ABA problem where
+// a value changes and then changes back. The value is guarded by a seqlock,
+// whose sequence number doubles as the generation: it is odd while a write is
+// in progress, and twice the generation otherwise. Readers never block
+// writers, but writers exclude one another.
+type VersionedFloat struct {
+	sl  seqlock // sequence number is 2*generation, plus one during a write
+	u64 atomic.Uint64
+}
+
+// NewVersionedFloat returns a new VersionedFloat initialized to initial, at
+// generation 0.
+func NewVersionedFloat(initial float64) *VersionedFloat {
+	v := new(VersionedFloat)
+	v.u64.Store(math.Float64bits(initial))
+	return v
+}
+
+// unlock releases the seqlock, publishing the write as the next generation,
+// which it returns.
+func (v *VersionedFloat) unlock() uint64 {
+	return v.sl.unlock() >> 1
+}
+
+// Add atomically adds delta to the value, and returns the new value and its
+// generation.
+func (v *VersionedFloat) Add(delta float64) (float64, uint64) {
+	v.sl.lock()
+	new := math.Float64frombits(v.u64.Load()) + delta
+	v.u64.Store(math.Float64bits(new))
+	return new, v.unlock()
+}
+
+// Load atomically loads the current value and its generation.
+func (v *VersionedFloat) Load() (float64, uint64) {
+	for {
+		seq := v.sl.beginRead()
+		f := math.Float64frombits(v.u64.Load())
+		if v.sl.validRead(seq) {
+			return f, seq >> 1
+		}
+	}
+}
+
+// Store atomically stores new, and returns its generation.
+func (v *VersionedFloat) Store(new float64) uint64 {
+	v.sl.lock()
+	v.u64.Store(math.Float64bits(new))
+	return v.unlock()
+}
+
+// Swap atomically stores new, and returns the previous value and the
+// generation of new.
+func (v *VersionedFloat) Swap(new float64) (float64, uint64) {
+	v.sl.lock()
+	old := math.Float64frombits(v.u64.Swap(math.Float64bits(new)))
+	return old, v.unlock()
+}
+
+// CompareVersionAndSwap atomically stores new when the current generation is
+// expectedGen, and returns true when the swap took place. Because every write
+// increments the generation, the swap fails whenever any write has taken place
+// since expectedGen was loaded, even one that restored the same value.
+func (v *VersionedFloat) CompareVersionAndSwap(expectedGen uint64, new float64) bool {
+	if !v.sl.tryLock(expectedGen << 1) {
+		return false
+	}
+	v.u64.Store(math.Float64bits(new))
+	v.unlock()
+	return true
+}
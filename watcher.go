@@ -0,0 +1,33 @@
+package atomic
+
+import "math"
+
+// Watcher reports whether an atomic float has changed since it last looked,
+// such as for a polling user interface that redraws only on change. Each
+// watcher tracks its own last-seen value, so any number of independent
+// watchers may watch the same atomic float. A watcher is not safe for
+// concurrent use; each goroutine should create its own.
+type Watcher struct {
+	af   AtomicFloat
+	last uint64
+	seen bool
+}
+
+// NewWatcher returns a new Watcher of af that has not yet seen its value.
+func NewWatcher(af AtomicFloat) *Watcher {
+	return &Watcher{af: af}
+}
+
+// LoadIfChanged atomically loads the current atomic float value, and returns it
+// along with whether it differs from the value the previous call returned, or
+// true on the first call. Values are compared by their bit patterns, so
+// reading NaN twice counts as unchanged, while a change between +0 and -0
+// counts as changed. Writes that restore the previously seen value between
+// calls are not detected.
+func (w *Watcher) LoadIfChanged() (v float64, changed bool) {
+	v = w.af.Load()
+	bits := math.Float64bits(v)
+	changed = !w.seen || bits != w.last
+	w.last, w.seen = bits, true
+	return v, changed
+}
@@ -0,0 +1,66 @@
+package atomic
+
+import (
+	"errors"
+	"math"
+	"sync/atomic"
+)
+
+// ErrNegativeWeight is returned by WeightedMean.Add when the weight is negative
+// or NaN.
+var ErrNegativeWeight = errors.New("atomic: weight must not be negative")
+
+// weightedState is an immutable snapshot of a weighted sum and the total of
+// its weights.
+type weightedState struct {
+	sum    float64 // sum of value*weight
+	weight float64 // sum of weight
+}
+
+// WeightedMean is a concurrency-safe accumulator of the weighted arithmetic
+// mean of a stream of values. The weighted sum and total weight are swapped
+// together through a pointer to a freshly allocated snapshot, so readers always
+// observe a consistent pair, and every Add allocates. The zero value is an empty
+// WeightedMean ready to use.
+type WeightedMean struct {
+	p atomic.Pointer[weightedState]
+}
+
+// Add atomically includes value, with the specified weight, in the mean.
+// Negative and NaN weights are rejected with ErrNegativeWeight, leaving the
+// mean unchanged. A weight of zero has no effect.
+func (w *WeightedMean) Add(value, weight float64) error {
+	if !(weight >= 0) {
+		return ErrNegativeWeight
+	}
+	for {
+		old := w.p.Load()
+		var next weightedState
+		if old != nil {
+			next = *old
+		}
+		next.sum += value * weight
+		next.weight += weight
+		if w.p.CompareAndSwap(old, &next) {
+			return nil
+		}
+	}
+}
+
+// Mean returns the weighted mean of the values, or NaN when the total weight is
+// zero.
+func (w *WeightedMean) Mean() float64 {
+	s := w.p.Load()
+	if s == nil || s.weight == 0 {
+		return math.NaN()
+	}
+	return s.sum / s.weight
+}
+
+// TotalWeight returns the sum of the weights of the values.
+func (w *WeightedMean) TotalWeight() float64 {
+	if s := w.p.Load(); s != nil {
+		return s.weight
+	}
+	return 0
+}